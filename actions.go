@@ -0,0 +1,225 @@
+package ui
+
+import (
+	"fmt"
+	"net/http"
+	"slices"
+	"strconv"
+
+	"github.com/ppacer/core/api"
+	"github.com/ppacer/core/dag"
+)
+
+const (
+	auditActionCancel     = "cancel"
+	auditActionCancelTask = "cancel_task"
+)
+
+// DAG run statuses of DAG runs which are not finished yet.
+var activeRunStatuses = []string{
+	dag.RunReadyToSchedule.String(),
+	dag.RunScheduled.String(),
+	dag.RunRunning.String(),
+}
+
+// Task statuses of tasks which are not finished yet.
+var activeTaskStatuses = []string{
+	dag.TaskScheduled.String(),
+	dag.TaskRunning.String(),
+	dag.TaskFailedPendingRetry.String(),
+	dag.TaskRestarting.String(),
+}
+
+func isActiveRunStatus(status string) bool {
+	return slices.Contains(activeRunStatuses, status)
+}
+
+func isActiveTaskStatus(status string) bool {
+	return slices.Contains(activeTaskStatuses, status)
+}
+
+// DagrunActions describes which actions are available for a DAG run on DAG
+// run details page. Actions which are not supported by Scheduler client are
+// not available.
+type DagrunActions struct {
+	Restart     bool
+	Cancel      bool
+	CancelTasks []DagrunTask
+	MarkSuccess bool
+	MarkFailed  bool
+}
+
+// Any checks if any action is available.
+func (a DagrunActions) Any() bool {
+	return a.Restart || a.Cancel || len(a.CancelTasks) > 0 || a.Mark()
+}
+
+// Mark checks if DAG run can be marked with any status.
+func (a DagrunActions) Mark() bool {
+	return a.MarkSuccess || a.MarkFailed
+}
+
+// Determines actions available for given DAG run.
+func (pdrd *pageDagRunDetails) dagrunActions(d DagrunDetails) DagrunActions {
+	actions := DagrunActions{
+		Restart: d.Status == dag.RunFailed.String(),
+	}
+	if _, ok := pdrd.schedApi.(DagRunCanceller); ok {
+		actions.Cancel = isActiveRunStatus(d.Status)
+		for _, task := range d.Tasks {
+			if !task.TaskNoStarted && isActiveTaskStatus(task.Status) {
+				actions.CancelTasks = append(actions.CancelTasks, task)
+			}
+		}
+	}
+	if _, ok := pdrd.schedApi.(DagRunStatusSetter); ok {
+		actions.MarkSuccess = d.Status != dag.RunSuccess.String()
+		actions.MarkFailed = d.Status != dag.RunFailed.String()
+	}
+	return actions
+}
+
+// HTTP handler for cancelling DAG run given by runId form value. Only DAG
+// runs which are not finished yet can be cancelled.
+func (pdrd *pageDagRunDetails) CancelDagRunHandler(
+	w http.ResponseWriter, r *http.Request,
+) {
+	canceller, ok := pdrd.schedApi.(DagRunCanceller)
+	runId, err := strconv.Atoi(r.FormValue("runId"))
+	if err != nil {
+		pdrd.logger.Error("Invalid runId for cancelling DAG run", "runId",
+			r.FormValue("runId"))
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+	if !ok {
+		pdrd.renderActionsErr(w, runId,
+			"Connected Scheduler does not support cancelling DAG runs")
+		return
+	}
+	drd, err := pdrd.schedApi.UIDagrunDetails(runId)
+	if err != nil {
+		pdrd.logger.Error("Cannot read DAG run details", "runId", runId, "err",
+			err.Error())
+		pdrd.renderActionsErr(w, runId, "Cannot read DAG run details")
+		return
+	}
+	if !isActiveRunStatus(drd.Status) {
+		pdrd.renderActionsErr(w, runId, fmt.Sprintf(
+			"Cannot cancel DAG run - it's already %s", drd.Status))
+		return
+	}
+
+	pdrd.logger.Info("Cancelling DAG run", "runId", runId, "dagId", drd.DagId)
+	cancelErr := canceller.CancelDagRun(runId)
+	event := AuditEvent{
+		User:   requestUser(r, pdrd.config.UserHeader),
+		Action: auditActionCancel,
+		RunId:  drd.RunId,
+		DagId:  drd.DagId,
+	}
+	if cancelErr != nil {
+		event.Err = cancelErr.Error()
+	}
+	pdrd.audit.add(event)
+	if cancelErr != nil {
+		pdrd.logger.Error("Error while cancelling DAG run", "runId", runId,
+			"err", cancelErr.Error())
+		pdrd.renderActionsErr(w, runId, "Cannot cancel DAG run")
+		return
+	}
+	w.Header().Set("HX-Redirect", fmt.Sprintf("/dagruns/%d", runId))
+	w.WriteHeader(http.StatusOK)
+}
+
+// HTTP handler for cancelling single DAG run task attempt given by runId,
+// taskId and retry form values. Only tasks which are not finished yet can be
+// cancelled.
+func (pdrd *pageDagRunDetails) CancelTaskHandler(
+	w http.ResponseWriter, r *http.Request,
+) {
+	canceller, ok := pdrd.schedApi.(DagRunCanceller)
+	runId, runErr := strconv.Atoi(r.FormValue("runId"))
+	retry, retryErr := strconv.Atoi(r.FormValue("retry"))
+	taskId := r.FormValue("taskId")
+	if runErr != nil || retryErr != nil || taskId == "" {
+		pdrd.logger.Error("Invalid input for cancelling task", "runId",
+			r.FormValue("runId"), "taskId", taskId, "retry",
+			r.FormValue("retry"))
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+	if !ok {
+		pdrd.renderActionsErr(w, runId,
+			"Connected Scheduler does not support cancelling tasks")
+		return
+	}
+	drd, err := pdrd.schedApi.UIDagrunDetails(runId)
+	if err != nil {
+		pdrd.logger.Error("Cannot read DAG run details", "runId", runId, "err",
+			err.Error())
+		pdrd.renderActionsErr(w, runId, "Cannot read DAG run details")
+		return
+	}
+	idx := slices.IndexFunc(drd.Tasks, func(t api.UIDagrunTask) bool {
+		return t.TaskId == taskId && t.Retry == retry
+	})
+	if idx < 0 {
+		pdrd.renderActionsErr(w, runId, fmt.Sprintf(
+			"Cannot cancel task %s - retry %d does not exist", taskId, retry))
+		return
+	}
+	task := drd.Tasks[idx]
+	if !isActiveTaskStatus(task.Status) {
+		pdrd.renderActionsErr(w, runId, fmt.Sprintf(
+			"Cannot cancel task %s - it's already %s", taskId, task.Status))
+		return
+	}
+
+	pdrd.logger.Info("Cancelling task", "runId", runId, "taskId", taskId,
+		"retry", retry)
+	cancelErr := canceller.CancelTask(runId, taskId, retry)
+	event := AuditEvent{
+		User:    requestUser(r, pdrd.config.UserHeader),
+		Action:  auditActionCancelTask,
+		RunId:   drd.RunId,
+		DagId:   drd.DagId,
+		TaskId:  taskId,
+		Details: fmt.Sprintf("retry %d", retry),
+	}
+	if cancelErr != nil {
+		event.Err = cancelErr.Error()
+	}
+	pdrd.audit.add(event)
+	if cancelErr != nil {
+		pdrd.logger.Error("Error while cancelling task", "runId", runId,
+			"taskId", taskId, "retry", retry, "err", cancelErr.Error())
+		pdrd.renderActionsErr(w, runId, "Cannot cancel task")
+		return
+	}
+	w.Header().Set("HX-Redirect", fmt.Sprintf("/dagruns/%d", runId))
+	w.WriteHeader(http.StatusOK)
+}
+
+// Renders DAG run actions component with given error message, based on
+// the current state of the DAG run.
+func (pdrd *pageDagRunDetails) renderActionsErr(
+	w http.ResponseWriter, runId int, msg string,
+) {
+	page := *pdrd
+	page.Errors = map[string]string{dagrunActionsErr: msg}
+	drd, err := pdrd.schedApi.UIDagrunDetails(runId)
+	if err != nil {
+		pdrd.logger.Error("Cannot read DAG run details", "runId", runId, "err",
+			err.Error())
+	}
+	page.Details = pdrd.prepareDagrunTaskDetails(drd, maxTaskIndent)
+	page.Details.Actions = page.dagrunActions(page.Details)
+
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	renderErr := pdrd.templates.Render(w, "dagrun_details_actions", &page)
+	if renderErr != nil {
+		pdrd.logger.Error("Cannot render <dagrun_details_actions>", "err",
+			renderErr.Error())
+	}
+}
@@ -0,0 +1,94 @@
+package ui
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"time"
+)
+
+// Timeout of requests sent by WebhookNotifier created with NewWebhookNotifier.
+const webhookTimeout = 5 * time.Second
+
+// logNotifier logs alert state changes. It's always registered.
+type logNotifier struct {
+	logger *slog.Logger
+}
+
+func newLogNotifier(logger *slog.Logger) logNotifier {
+	if logger == nil {
+		logger = defaultLogger()
+	}
+	return logNotifier{logger: logger}
+}
+
+// Notify logs given alert state change.
+func (ln logNotifier) Notify(_ context.Context, e AlertEvent) error {
+	if e.Firing {
+		ln.logger.Warn("Alert firing", "rule", e.Rule.Name, "desc",
+			e.Rule.Describe(), "value", e.Value, "msg", e.Message)
+		return nil
+	}
+	ln.logger.Info("Alert resolved", "rule", e.Rule.Name, "desc",
+		e.Rule.Describe(), "value", e.Value, "msg", e.Message)
+	return nil
+}
+
+// WebhookNotifier sends alert state changes as JSON in POST requests to
+// given URL.
+type WebhookNotifier struct {
+	Url    string
+	Client *http.Client
+}
+
+// NewWebhookNotifier creates new WebhookNotifier with default HTTP client.
+func NewWebhookNotifier(url string) *WebhookNotifier {
+	return &WebhookNotifier{
+		Url:    url,
+		Client: &http.Client{Timeout: webhookTimeout},
+	}
+}
+
+// Body of requests sent by WebhookNotifier.
+type webhookAlert struct {
+	Ts          time.Time `json:"ts"`
+	Rule        string    `json:"rule"`
+	Description string    `json:"description"`
+	Firing      bool      `json:"firing"`
+	Value       int       `json:"value"`
+	Message     string    `json:"message"`
+}
+
+// Notify sends given alert state change to the webhook URL. The request is
+// aborted, when ctx is done.
+func (wn *WebhookNotifier) Notify(ctx context.Context, e AlertEvent) error {
+	body, err := json.Marshal(webhookAlert{
+		Ts:          e.Ts,
+		Rule:        e.Rule.Name,
+		Description: e.Rule.Describe(),
+		Firing:      e.Firing,
+		Value:       e.Value,
+		Message:     e.Message,
+	})
+	if err != nil {
+		return fmt.Errorf("cannot serialize alert: %w", err)
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, wn.Url,
+		bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("cannot prepare webhook request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	resp, err := wn.Client.Do(req)
+	if err != nil {
+		return fmt.Errorf("cannot send alert to webhook: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode >= 300 {
+		return fmt.Errorf("webhook responded with status %s", resp.Status)
+	}
+	return nil
+}
@@ -0,0 +1,596 @@
+package ui
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"regexp"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/ppacer/core/api"
+	"github.com/ppacer/core/dag"
+)
+
+const (
+	// Metric of alert rules which counts failed DAG runs within the rule
+	// window. The remaining metrics are keys of statsMetrics.
+	alertMetricDagFailedRuns = "dag_failed_runs"
+
+	// Maximum number of the latest failed DAG runs read, when evaluating
+	// alert rule on failed DAG runs.
+	maxAlertFailedRuns = 1000
+
+	// Maximum number of alert state changes kept in memory.
+	maxAlertEvents = 200
+
+	// Maximum number of alert state changes waiting to be dispatched to
+	// notifiers. When notifiers cannot keep up, new events are dropped.
+	maxPendingNotifications = 100
+)
+
+// Conditions of alert rules.
+const (
+	AlertAbove   = ">"
+	AlertBelow   = "<"
+	AlertGrowing = "growing"
+)
+
+// Alert rule names are used in URLs and logs, so only simple characters are
+// allowed.
+var alertRuleNameRegex = regexp.MustCompile(`^[A-Za-z0-9_.-]{1,100}$`)
+
+// AlertRule represents threshold-based rule evaluated by the UI based on
+// sampled DAG runs statistics. Metric is a key of DAG runs statistics metric
+// (like tasks_queue or goroutines) or dag_failed_runs, which counts failed DAG
+// runs of DagId (or all DAGs, when it's empty) finished within the last Window.
+//
+// For statistics metrics and condition ">" or "<", the rule fires when all
+// samples within the last Window are above or below Threshold. When Window is
+// zero, only the latest sample is checked. For condition "growing", the rule
+// fires when the average of the last quarter of samples within Window exceeds
+// the average of the first quarter by more than Threshold.
+type AlertRule struct {
+	Name      string
+	Metric    string
+	DagId     string
+	Condition string
+	Threshold int
+	Window    time.Duration
+
+	// FromConfig is set for rules defined in Config.AlertRules. Those rules
+	// cannot be removed via the UI.
+	FromConfig bool
+}
+
+// Describe returns human-readable form of the rule, like "Tasks queued > 500
+// for 5m".
+func (r AlertRule) Describe() string {
+	if r.Metric == alertMetricDagFailedRuns {
+		dagId := "any DAG"
+		if r.DagId != "" {
+			dagId = "DAG " + r.DagId
+		}
+		return fmt.Sprintf("Failed runs of %s %s %d in %s", dagId,
+			r.Condition, r.Threshold, formatRuleWindow(r.Window))
+	}
+	title := r.Metric
+	if metric, ok := findStatsMetric(r.Metric); ok {
+		title = metric.Title
+	}
+	if r.Condition == AlertGrowing {
+		desc := fmt.Sprintf("%s growing for %s", title,
+			formatRuleWindow(r.Window))
+		if r.Threshold > 0 {
+			desc += fmt.Sprintf(" by more than %d", r.Threshold)
+		}
+		return desc
+	}
+	if r.Window == 0 {
+		return fmt.Sprintf("%s %s %d", title, r.Condition, r.Threshold)
+	}
+	return fmt.Sprintf("%s %s %d for %s", title, r.Condition, r.Threshold,
+		formatRuleWindow(r.Window))
+}
+
+// Validate checks if the rule is well defined.
+func (r AlertRule) Validate() error {
+	if !alertRuleNameRegex.MatchString(r.Name) {
+		return errors.New("name must have up to 100 letters, digits, '_', " +
+			"'.' or '-' characters")
+	}
+	_, isStatsMetric := findStatsMetric(r.Metric)
+	if !isStatsMetric && r.Metric != alertMetricDagFailedRuns {
+		return fmt.Errorf("unknown metric %q", r.Metric)
+	}
+	if r.DagId != "" && r.Metric != alertMetricDagFailedRuns {
+		return fmt.Errorf("DAG ID can be set only for %s metric",
+			alertMetricDagFailedRuns)
+	}
+	if !slices.Contains(alertConditions, r.Condition) {
+		return fmt.Errorf("unknown condition %q, expected one of: %s",
+			r.Condition, strings.Join(alertConditions, ", "))
+	}
+	if r.Condition == AlertGrowing && r.Metric == alertMetricDagFailedRuns {
+		return fmt.Errorf("condition %q is not supported for %s metric",
+			AlertGrowing, alertMetricDagFailedRuns)
+	}
+	if r.Threshold < 0 {
+		return errors.New("threshold cannot be negative")
+	}
+	if r.Window < 0 || r.Window > statsHistoryWindow {
+		return fmt.Errorf("window must be between 0 and %s",
+			formatRuleWindow(statsHistoryWindow))
+	}
+	if r.Window == 0 && (r.Condition == AlertGrowing ||
+		r.Metric == alertMetricDagFailedRuns) {
+		return errors.New("window must be positive")
+	}
+	return nil
+}
+
+// Conditions of alert rules.
+var alertConditions = []string{AlertAbove, AlertBelow, AlertGrowing}
+
+func findStatsMetric(key string) (statsMetric, bool) {
+	for _, metric := range statsMetrics {
+		if metric.Key == key {
+			return metric, true
+		}
+	}
+	return statsMetric{}, false
+}
+
+// Formats rule window without redundant zero units, like 15m instead of
+// 15m0s.
+func formatRuleWindow(d time.Duration) string {
+	s := d.String()
+	if strings.HasSuffix(s, "m0s") {
+		s = s[:len(s)-2]
+	}
+	if strings.HasSuffix(s, "h0m") {
+		s = s[:len(s)-2]
+	}
+	return s
+}
+
+// AlertState represents the latest evaluation of an alert rule. Pending is
+// set, when there is not enough data to evaluate the rule yet. Since is the
+// time of the latest change of Firing.
+type AlertState struct {
+	Rule    AlertRule
+	Firing  bool
+	Pending bool
+	Value   int
+	Since   time.Time
+	EvalTs  time.Time
+	Message string
+	Err     string
+}
+
+// SinceDisplay returns formatted time of the latest state change.
+func (s AlertState) SinceDisplay() string {
+	if s.Since.IsZero() {
+		return ""
+	}
+	return s.Since.Format(auditDisplayFormat)
+}
+
+// AlertEvent represents change of alert state, which is dispatched to
+// notifiers. Firing is false, when the alert is resolved.
+type AlertEvent struct {
+	Ts      time.Time
+	Rule    AlertRule
+	Firing  bool
+	Value   int
+	Message string
+}
+
+// TsDisplay returns formatted timestamp of the event.
+func (e AlertEvent) TsDisplay() string { return e.Ts.Format(auditDisplayFormat) }
+
+// AlertNotifier is notified about alert state changes. Notifiers are called
+// sequentially in a separate goroutine, so slow notifier doesn't delay
+// evaluation of alert rules, but it delays notifying other notifiers. The
+// context is cancelled, when the UI is closed.
+type AlertNotifier interface {
+	Notify(context.Context, AlertEvent) error
+}
+
+// alertManager periodically evaluates alert rules based on sampled DAG runs
+// statistics and DAG runs read from the Scheduler. Changes of alert states are
+// dispatched to notifiers. Rules added via the UI are persisted in the history
+// store, when it's configured.
+type alertManager struct {
+	sync.RWMutex
+	rules     []AlertRule
+	states    map[string]AlertState
+	events    []AlertEvent
+	notifiers []AlertNotifier
+	pending   chan AlertEvent
+
+	runs     *dagRunSource
+	history  *statsHistory
+	store    *historyStore
+	interval time.Duration
+	logger   *slog.Logger
+}
+
+// Creates new alertManager with rules from the config and rules added via the
+// UI loaded from the history store. Invalid rules from the config are logged
+// and skipped.
+func newAlertManager(
+	runs *dagRunSource, history *statsHistory, store *historyStore,
+	configRules []AlertRule, notifiers []AlertNotifier,
+	interval time.Duration, logger *slog.Logger,
+) *alertManager {
+	if logger == nil {
+		logger = defaultLogger()
+	}
+	am := &alertManager{
+		states:    map[string]AlertState{},
+		notifiers: notifiers,
+		pending:   make(chan AlertEvent, maxPendingNotifications),
+		runs:      runs,
+		history:   history,
+		store:     store,
+		interval:  interval,
+		logger:    logger,
+	}
+	for _, rule := range configRules {
+		rule.FromConfig = true
+		if err := am.addRule(rule); err != nil {
+			logger.Error("Invalid alert rule in config", "rule", rule.Name,
+				"err", err.Error())
+		}
+	}
+	storedRules, err := store.alertRules()
+	if err != nil {
+		logger.Error("Cannot load alert rules from history store", "err",
+			err.Error())
+	}
+	for _, rule := range storedRules {
+		if err := am.addRule(rule); err != nil {
+			logger.Error("Invalid alert rule in history store", "rule",
+				rule.Name, "err", err.Error())
+		}
+	}
+	return am
+}
+
+// Adds new rule, when it's valid and its name is unique.
+func (am *alertManager) addRule(rule AlertRule) error {
+	if err := rule.Validate(); err != nil {
+		return err
+	}
+	am.Lock()
+	defer am.Unlock()
+	for _, existing := range am.rules {
+		if existing.Name == rule.Name {
+			return fmt.Errorf("alert rule %s already exists", rule.Name)
+		}
+	}
+	am.rules = append(am.rules, rule)
+	am.states[rule.Name] = AlertState{Rule: rule, Pending: true}
+	return nil
+}
+
+// create adds new rule defined via the UI and persists it.
+func (am *alertManager) create(rule AlertRule) error {
+	rule.FromConfig = false
+	if err := am.addRule(rule); err != nil {
+		return err
+	}
+	am.store.saveAlertRule(rule)
+	return nil
+}
+
+// remove deletes rule defined via the UI. Firing alert of the rule is
+// resolved.
+func (am *alertManager) remove(name string) error {
+	am.Lock()
+	idx := slices.IndexFunc(am.rules, func(r AlertRule) bool {
+		return r.Name == name
+	})
+	if idx < 0 {
+		am.Unlock()
+		return fmt.Errorf("alert rule %s does not exist", name)
+	}
+	rule := am.rules[idx]
+	if rule.FromConfig {
+		am.Unlock()
+		return fmt.Errorf("alert rule %s is defined in config", name)
+	}
+	am.rules = slices.Delete(am.rules, idx, idx+1)
+	state := am.states[name]
+	delete(am.states, name)
+	var resolved []AlertEvent
+	if state.Firing {
+		resolved = append(resolved, am.recordEvent(AlertEvent{
+			Ts:      time.Now(),
+			Rule:    rule,
+			Value:   state.Value,
+			Message: "alert rule removed",
+		}))
+	}
+	am.Unlock()
+
+	am.store.deleteAlertRule(name)
+	am.notify(resolved)
+	return nil
+}
+
+// list returns states of all rules in order of their definition.
+func (am *alertManager) list() []AlertState {
+	am.RLock()
+	defer am.RUnlock()
+	states := make([]AlertState, 0, len(am.rules))
+	for _, rule := range am.rules {
+		states = append(states, am.states[rule.Name])
+	}
+	return states
+}
+
+// firing returns states of firing alerts.
+func (am *alertManager) firing() []AlertState {
+	states := am.list()
+	return slices.DeleteFunc(states, func(s AlertState) bool {
+		return !s.Firing
+	})
+}
+
+// latestEvents returns alert state changes starting from the latest one.
+func (am *alertManager) latestEvents() []AlertEvent {
+	am.RLock()
+	defer am.RUnlock()
+	events := slices.Clone(am.events)
+	slices.Reverse(events)
+	return events
+}
+
+// run evaluates alert rules every interval, until ctx is done. It should be
+// started in a separate goroutine.
+func (am *alertManager) run(ctx context.Context) {
+	ticker := time.NewTicker(am.interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			am.evaluate(time.Now())
+		}
+	}
+}
+
+// evaluate evaluates all rules at given time, updates their states and
+// notifies about state changes.
+func (am *alertManager) evaluate(now time.Time) {
+	am.RLock()
+	rules := slices.Clone(am.rules)
+	am.RUnlock()
+
+	results := make([]AlertState, 0, len(rules))
+	for _, rule := range rules {
+		results = append(results, am.evaluateRule(rule, now))
+	}
+
+	am.Lock()
+	var changes []AlertEvent
+	for _, result := range results {
+		prev, exists := am.states[result.Rule.Name]
+		if !exists {
+			// Rule was removed during evaluation.
+			continue
+		}
+		result.Since = prev.Since
+		if result.Firing != prev.Firing && !result.Pending {
+			result.Since = now
+			changes = append(changes, am.recordEvent(AlertEvent{
+				Ts:      now,
+				Rule:    result.Rule,
+				Firing:  result.Firing,
+				Value:   result.Value,
+				Message: result.Message,
+			}))
+		} else if result.Pending {
+			// Keep firing alert until there is enough data to resolve it.
+			result.Firing = prev.Firing
+		}
+		am.states[result.Rule.Name] = result
+	}
+	am.Unlock()
+	am.notify(changes)
+}
+
+// Appends event to the list of recent state changes. It has to be called
+// with the lock held.
+func (am *alertManager) recordEvent(e AlertEvent) AlertEvent {
+	am.events = append(am.events, e)
+	if len(am.events) > maxAlertEvents {
+		am.events = am.events[len(am.events)-maxAlertEvents:]
+	}
+	return e
+}
+
+// addNotifier registers notifier of alert state changes.
+func (am *alertManager) addNotifier(notifier AlertNotifier) {
+	am.Lock()
+	defer am.Unlock()
+	am.notifiers = append(am.notifiers, notifier)
+}
+
+// Queues events to be dispatched to notifiers. It doesn't block, events which
+// don't fit into the queue are dropped and logged.
+func (am *alertManager) notify(events []AlertEvent) {
+	for _, e := range events {
+		select {
+		case am.pending <- e:
+		default:
+			am.logger.Error("Alert notifications queue is full, dropping "+
+				"notification", "rule", e.Rule.Name, "firing", e.Firing)
+		}
+	}
+}
+
+// dispatch sends queued events to all notifiers, until ctx is done. Events
+// still queued at that point are dropped. Errors are only logged. It should be
+// started in a separate goroutine.
+func (am *alertManager) dispatch(ctx context.Context) {
+	for {
+		select {
+		case <-ctx.Done():
+			am.dropPending()
+			return
+		case e := <-am.pending:
+			am.RLock()
+			notifiers := slices.Clone(am.notifiers)
+			am.RUnlock()
+			for _, notifier := range notifiers {
+				if ctx.Err() != nil {
+					break
+				}
+				if err := notifier.Notify(ctx, e); err != nil {
+					am.logger.Error("Cannot notify about alert", "rule",
+						e.Rule.Name, "firing", e.Firing, "err", err.Error())
+				}
+			}
+		}
+	}
+}
+
+// Drains the queue of events, which were not dispatched before the UI was
+// closed.
+func (am *alertManager) dropPending() {
+	dropped := 0
+	for {
+		select {
+		case <-am.pending:
+			dropped++
+		default:
+			if dropped > 0 {
+				am.logger.Warn("Dropping alert notifications, UI is closed",
+					"dropped", dropped)
+			}
+			return
+		}
+	}
+}
+
+// Evaluates single rule at given time.
+func (am *alertManager) evaluateRule(rule AlertRule, now time.Time) AlertState {
+	state := AlertState{Rule: rule, EvalTs: now}
+	if rule.Metric == alertMetricDagFailedRuns {
+		count, err := am.failedRunsSince(rule.DagId, now.Add(-rule.Window))
+		if err != nil {
+			am.logger.Warn("Cannot evaluate alert rule", "rule", rule.Name,
+				"err", err.Error())
+			state.Pending, state.Err = true, err.Error()
+			return state
+		}
+		state.Value = count
+		state.Firing = compareAlertValue(count, rule.Condition, rule.Threshold)
+		state.Message = fmt.Sprintf("%d failed DAG runs in the last %s",
+			count, formatRuleWindow(rule.Window))
+		return state
+	}
+
+	metric, _ := findStatsMetric(rule.Metric)
+	samples := am.history.since(now.Add(-rule.Window))
+	if len(samples) == 0 {
+		state.Pending, state.Message = true, "no samples yet"
+		return state
+	}
+	last := metric.Value(samples[len(samples)-1].Stats)
+	state.Value = last
+	if rule.Window == 0 {
+		state.Firing = compareAlertValue(last, rule.Condition, rule.Threshold)
+		state.Message = fmt.Sprintf("%s is %d", metric.Title, last)
+		return state
+	}
+	// Window has to be covered by samples, otherwise alert would fire right
+	// after the UI started.
+	if samples[0].Ts.Sub(now.Add(-rule.Window)) > am.interval {
+		state.Pending = true
+		state.Message = fmt.Sprintf("not enough samples to cover %s",
+			formatRuleWindow(rule.Window))
+		return state
+	}
+
+	if rule.Condition == AlertGrowing {
+		growth := samplesGrowth(samples, metric)
+		state.Firing = growth > float64(rule.Threshold)
+		state.Message = fmt.Sprintf("%s changed by %.1f within %s",
+			metric.Title, growth, formatRuleWindow(rule.Window))
+		return state
+	}
+	state.Firing = true
+	for _, s := range samples {
+		if !compareAlertValue(metric.Value(s.Stats), rule.Condition,
+			rule.Threshold) {
+			state.Firing = false
+			break
+		}
+	}
+	state.Message = fmt.Sprintf("%s is %d", metric.Title, last)
+	return state
+}
+
+// Counts failed DAG runs of given DAG (or all DAGs, when dagId is empty)
+// which status was updated not earlier than since.
+func (am *alertManager) failedRunsSince(
+	dagId string, since time.Time,
+) (int, error) {
+	dagruns, err := am.runs.query(DagRunQuery{
+		DagId:    dagId,
+		Statuses: []string{dag.RunFailed.String()},
+		Limit:    maxAlertFailedRuns,
+	})
+	if err != nil {
+		return 0, err
+	}
+	count := 0
+	for _, run := range dagruns {
+		if statusUpdatedSince(run, since) {
+			count++
+		}
+	}
+	return count, nil
+}
+
+func statusUpdatedSince(run api.UIDagrunRow, since time.Time) bool {
+	ts, err := parseTimestamp(run.StatusUpdateTs)
+	if err != nil {
+		return false
+	}
+	return !ts.Before(since)
+}
+
+func compareAlertValue(value int, condition string, threshold int) bool {
+	switch condition {
+	case AlertAbove:
+		return value > threshold
+	case AlertBelow:
+		return value < threshold
+	default:
+		return false
+	}
+}
+
+// Computes difference between average of the last quarter and the first
+// quarter of samples. Averages make it less sensitive to noise, than
+// comparing the first and the last sample.
+func samplesGrowth(samples []StatsSample, metric statsMetric) float64 {
+	quarter := max(len(samples)/4, 1)
+	avg := func(part []StatsSample) float64 {
+		sum := 0
+		for _, s := range part {
+			sum += metric.Value(s.Stats)
+		}
+		return float64(sum) / float64(len(part))
+	}
+	return avg(samples[len(samples)-quarter:]) - avg(samples[:quarter])
+}
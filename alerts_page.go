@@ -0,0 +1,203 @@
+package ui
+
+import (
+	"fmt"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	auditActionAddAlertRule    = "add_alert_rule"
+	auditActionDeleteAlertRule = "delete_alert_rule"
+
+	// Number of the latest alert state changes rendered on "Alerts" page.
+	alertEventsPageSize = 50
+)
+
+// Type pageAlerts keeps data required for "Alerts" (/alerts) page.
+type pageAlerts struct {
+	templates *templates
+	alerts    *alertManager
+	audit     *auditLog
+	logger    *slog.Logger
+	config    Config
+}
+
+func newPageAlerts(
+	alerts *alertManager, audit *auditLog, tmpl *templates,
+	logger *slog.Logger, config Config,
+) *pageAlerts {
+	if logger == nil {
+		logger = defaultLogger()
+	}
+	return &pageAlerts{
+		templates: tmpl,
+		alerts:    alerts,
+		audit:     audit,
+		logger:    logger,
+		config:    config,
+	}
+}
+
+// AlertsView represents data rendered on "Alerts" page.
+type AlertsView struct {
+	Page     string
+	Firing   []AlertState
+	Rules    []AlertState
+	Events   []AlertEvent
+	Form     AlertRuleForm
+	Interval time.Duration
+	Version  string
+}
+
+// AlertMetricOption represents metric which can be selected in alert rule
+// form.
+type AlertMetricOption struct {
+	Key   string
+	Title string
+}
+
+// AlertRuleForm represents form for adding alert rule. Fields keep raw values,
+// so the form can be rendered again, when values are invalid.
+type AlertRuleForm struct {
+	Name      string
+	Metric    string
+	DagId     string
+	Condition string
+	Threshold string
+	Window    string
+	Err       string
+}
+
+// Metrics returns metrics which can be selected in the form.
+func (f AlertRuleForm) Metrics() []AlertMetricOption {
+	options := []AlertMetricOption{
+		{alertMetricDagFailedRuns, "Failed runs of DAG"},
+	}
+	for _, metric := range statsMetrics {
+		options = append(options, AlertMetricOption{metric.Key, metric.Title})
+	}
+	return options
+}
+
+// Conditions returns conditions which can be selected in the form.
+func (f AlertRuleForm) Conditions() []string { return alertConditions }
+
+// parse validates the form and converts it into AlertRule.
+func (f AlertRuleForm) parse() (AlertRule, error) {
+	rule := AlertRule{
+		Name:      strings.TrimSpace(f.Name),
+		Metric:    f.Metric,
+		DagId:     strings.TrimSpace(f.DagId),
+		Condition: f.Condition,
+	}
+	threshold, err := strconv.Atoi(strings.TrimSpace(f.Threshold))
+	if err != nil {
+		return rule, fmt.Errorf("invalid threshold %q", f.Threshold)
+	}
+	rule.Threshold = threshold
+	if window := strings.TrimSpace(f.Window); window != "" {
+		rule.Window, err = time.ParseDuration(window)
+		if err != nil {
+			return rule, fmt.Errorf("invalid window %q, expected duration "+
+				"like 5m or 1h", f.Window)
+		}
+	}
+	return rule, rule.Validate()
+}
+
+// Main handler for "Alerts" page. It renders firing alerts, all alert rules
+// and the latest alert state changes.
+func (pa *pageAlerts) MainHandler(w http.ResponseWriter, r *http.Request) {
+	events := pa.alerts.latestEvents()
+	view := AlertsView{
+		Page:     "Alerts",
+		Firing:   pa.alerts.firing(),
+		Rules:    pa.alerts.list(),
+		Events:   events[:min(len(events), alertEventsPageSize)],
+		Form:     AlertRuleForm{Condition: AlertAbove, Threshold: "0"},
+		Interval: pa.alerts.interval,
+		Version:  Version,
+	}
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	renderErr := pa.templates.Render(w, "page_alerts", view)
+	if renderErr != nil {
+		pa.logger.Error("Cannot render <page_alerts>", "err",
+			renderErr.Error())
+	}
+}
+
+// BadgeHandler renders the navbar badge with the number of firing alerts.
+func (pa *pageAlerts) BadgeHandler(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	firing := len(pa.alerts.firing())
+	if err := pa.templates.Render(w, "alerts_badge", firing); err != nil {
+		pa.logger.Error("Cannot render <alerts_badge>", "err", err.Error())
+	}
+}
+
+// HTTP handler which adds alert rule defined in the form. On success the page
+// is reloaded, otherwise the form is rendered with an error.
+func (pa *pageAlerts) CreateRuleHandler(
+	w http.ResponseWriter, r *http.Request,
+) {
+	form := AlertRuleForm{
+		Name:      r.FormValue("name"),
+		Metric:    r.FormValue("metric"),
+		DagId:     r.FormValue("dagId"),
+		Condition: r.FormValue("condition"),
+		Threshold: r.FormValue("threshold"),
+		Window:    r.FormValue("window"),
+	}
+	rule, err := form.parse()
+	if err == nil {
+		err = pa.alerts.create(rule)
+	}
+	if err != nil {
+		form.Err = err.Error()
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+		renderErr := pa.templates.Render(w, "alert_rule_form", form)
+		if renderErr != nil {
+			pa.logger.Error("Cannot render <alert_rule_form>", "err",
+				renderErr.Error())
+		}
+		return
+	}
+	pa.logger.Info("Added alert rule", "rule", rule.Name)
+	pa.audit.add(AuditEvent{
+		User:    requestUser(r, pa.config.UserHeader),
+		Action:  auditActionAddAlertRule,
+		Details: fmt.Sprintf("%s: %s", rule.Name, rule.Describe()),
+	})
+	w.Header().Set("HX-Redirect", "/alerts")
+	w.WriteHeader(http.StatusOK)
+}
+
+// HTTP handler which removes alert rule given by name form value.
+func (pa *pageAlerts) DeleteRuleHandler(
+	w http.ResponseWriter, r *http.Request,
+) {
+	name := r.FormValue("name")
+	if name == "" {
+		pa.logger.Error("Empty name for deleting alert rule")
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+	err := pa.alerts.remove(name)
+	event := AuditEvent{
+		User:    requestUser(r, pa.config.UserHeader),
+		Action:  auditActionDeleteAlertRule,
+		Details: name,
+	}
+	if err != nil {
+		event.Err = err.Error()
+		pa.logger.Error("Cannot delete alert rule", "rule", name, "err",
+			err.Error())
+	}
+	pa.audit.add(event)
+	w.Header().Set("HX-Redirect", "/alerts")
+	w.WriteHeader(http.StatusOK)
+}
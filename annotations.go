@@ -0,0 +1,90 @@
+package ui
+
+import (
+	"sync"
+	"time"
+)
+
+// Maximum number of DAG runs with annotations kept in memory.
+const maxAnnotatedRuns = 1000
+
+// Annotation represents a note attached to a DAG run, like the reason of
+// manually marking DAG run or its task as succeeded. TaskId is empty for
+// annotations of the whole DAG run.
+type Annotation struct {
+	Ts     time.Time
+	User   string
+	RunId  int64
+	TaskId string
+	Text   string
+}
+
+// TsDisplay returns formatted timestamp of the annotation.
+func (a Annotation) TsDisplay() string { return a.Ts.Format(auditDisplayFormat) }
+
+// annotationStore keeps annotations of DAG runs. When annotations of more
+// than maxRuns DAG runs are stored, annotations of the least recently
+// annotated DAG run are dropped. Annotations are also persisted in the
+// history store, when it's configured.
+type annotationStore struct {
+	sync.RWMutex
+	maxRuns  int
+	byRun    map[int64][]Annotation
+	runOrder []int64
+	store    *historyStore
+}
+
+// Creates new annotationStore with annotations loaded from the history store,
+// when it's configured.
+func newAnnotationStore(maxRuns int, store *historyStore) *annotationStore {
+	as := &annotationStore{
+		maxRuns: maxRuns,
+		byRun:   make(map[int64][]Annotation),
+		store:   store,
+	}
+	annotations, err := store.latestAnnotations(maxRuns)
+	if err != nil {
+		store.logger.Error("Cannot load annotations from history store",
+			"err", err.Error())
+	}
+	for _, a := range annotations {
+		as.insert(a)
+	}
+	return as
+}
+
+// add attaches new annotation to the DAG run. If annotation timestamp is not
+// set, the current time is used.
+func (as *annotationStore) add(a Annotation) {
+	if a.Ts.IsZero() {
+		a.Ts = time.Now()
+	}
+	as.store.saveAnnotation(a)
+	as.insert(a)
+}
+
+// Attaches annotation to the DAG run in memory.
+func (as *annotationStore) insert(a Annotation) {
+	as.Lock()
+	defer as.Unlock()
+	if _, exists := as.byRun[a.RunId]; !exists {
+		as.runOrder = append(as.runOrder, a.RunId)
+	}
+	as.byRun[a.RunId] = append(as.byRun[a.RunId], a)
+	for len(as.runOrder) > as.maxRuns {
+		delete(as.byRun, as.runOrder[0])
+		as.runOrder = as.runOrder[1:]
+	}
+}
+
+// list returns annotations of given DAG run, starting from the newest one.
+func (as *annotationStore) list(runId int64) []Annotation {
+	as.RLock()
+	defer as.RUnlock()
+	annotations := as.byRun[runId]
+	result := make([]Annotation, len(annotations))
+	for i, a := range annotations {
+		result[len(annotations)-1-i] = a
+	}
+	return result
+}
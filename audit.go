@@ -0,0 +1,163 @@
+package ui
+
+import (
+	"log/slog"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	// User name used in the audit log, when request does not contain user
+	// header.
+	anonymousUser = "anonymous"
+
+	// Maximum number of events kept in the audit log.
+	maxAuditEvents = 5000
+
+	// Number of the latest events rendered on the audit log page.
+	auditPageSize = 200
+
+	auditDisplayFormat = "2006-01-02 15:04:05 MST"
+)
+
+// AuditEvent represents a single action performed by a user via the UI, like
+// restarting a DAG run. Reason is set for actions which require user to
+// justify them. Err is empty, when the action succeeded.
+type AuditEvent struct {
+	Ts      time.Time
+	User    string
+	Action  string
+	RunId   int64
+	DagId   string
+	TaskId  string
+	Details string
+	Reason  string
+	Err     string
+}
+
+// TsDisplay returns formatted timestamp of the event.
+func (e AuditEvent) TsDisplay() string { return e.Ts.Format(auditDisplayFormat) }
+
+// auditLog keeps the latest actions performed via the UI. When the log is
+// full, the oldest events are dropped. All events are also logged and
+// persisted in the history store, when it's configured.
+type auditLog struct {
+	sync.RWMutex
+	maxEvents int
+	events    []AuditEvent
+	store     *historyStore
+	logger    *slog.Logger
+}
+
+// Creates new auditLog with the latest events loaded from the history store,
+// when it's configured.
+func newAuditLog(
+	maxEvents int, store *historyStore, logger *slog.Logger,
+) *auditLog {
+	if logger == nil {
+		logger = defaultLogger()
+	}
+	events, err := store.latestAuditEvents(maxEvents)
+	if err != nil {
+		logger.Error("Cannot load audit events from history store", "err",
+			err.Error())
+	}
+	return &auditLog{
+		maxEvents: maxEvents,
+		events:    events,
+		store:     store,
+		logger:    logger,
+	}
+}
+
+// add appends new event to the audit log. If event timestamp is not set, the
+// current time is used.
+func (al *auditLog) add(e AuditEvent) {
+	if e.Ts.IsZero() {
+		e.Ts = time.Now()
+	}
+	al.logger.Info("Audit", "user", e.User, "action", e.Action, "runId",
+		e.RunId, "dagId", e.DagId, "taskId", e.TaskId, "details", e.Details,
+		"reason", e.Reason, "err", e.Err)
+	al.store.saveAuditEvent(e)
+
+	al.Lock()
+	defer al.Unlock()
+	al.events = append(al.events, e)
+	if len(al.events) > al.maxEvents {
+		al.events = al.events[len(al.events)-al.maxEvents:]
+	}
+}
+
+// list returns at most limit the latest events, starting from the newest one.
+// If runId is positive, only events of that DAG run are returned.
+func (al *auditLog) list(runId int64, limit int) []AuditEvent {
+	al.RLock()
+	defer al.RUnlock()
+	result := make([]AuditEvent, 0)
+	for i := len(al.events) - 1; i >= 0 && len(result) < limit; i-- {
+		if runId > 0 && al.events[i].RunId != runId {
+			continue
+		}
+		result = append(result, al.events[i])
+	}
+	return result
+}
+
+// requestUser returns name of the user who sent the request, based on given
+// header. When the header is not set, anonymousUser is returned.
+func requestUser(r *http.Request, header string) string {
+	if header == "" {
+		return anonymousUser
+	}
+	user := strings.TrimSpace(r.Header.Get(header))
+	if user == "" {
+		return anonymousUser
+	}
+	return user
+}
+
+// Type pageAudit keeps dependencies required for "Audit log" (/audit) page.
+type pageAudit struct {
+	templates *templates
+	audit     *auditLog
+	logger    *slog.Logger
+}
+
+// newPageAudit initialize new state for audit log page.
+func newPageAudit(
+	audit *auditLog, tmpl *templates, logger *slog.Logger,
+) *pageAudit {
+	if logger == nil {
+		logger = defaultLogger()
+	}
+	return &pageAudit{templates: tmpl, audit: audit, logger: logger}
+}
+
+// AuditEvents represents the latest audit log events, optionally limited to
+// a single DAG run.
+type AuditEvents struct {
+	Page    string
+	RunId   int64
+	Events  []AuditEvent
+	Version string
+}
+
+// MainHandler renders the latest audit log events. Events might be limited to
+// given DAG run using runId query parameter.
+func (pa *pageAudit) MainHandler(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	runId, _ := strconv.ParseInt(r.URL.Query().Get("runId"), 10, 64)
+	events := AuditEvents{
+		Page:    "History",
+		RunId:   runId,
+		Events:  pa.audit.list(runId, auditPageSize),
+		Version: Version,
+	}
+	if err := pa.templates.Render(w, "page_audit", events); err != nil {
+		pa.logger.Error("Cannot render <page_audit>", "err", err.Error())
+	}
+}
@@ -0,0 +1,464 @@
+package ui
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/ppacer/core/api"
+	"github.com/ppacer/core/dag/schedule"
+	"github.com/ppacer/core/scheduler"
+	"github.com/ppacer/core/timeutils"
+)
+
+const (
+	backfillErr            = "backfillErr"
+	backfillFormTsFormat   = "2006-01-02T15:04"
+	backfillDisplayFormat  = "2006-01-02 15:04:05 MST"
+	maxBackfillRuns        = 1000
+	maxBackfillJobs        = 20
+	maxBackfillConcurrency = 64
+)
+
+// Statuses of a single backfill item (DAG run to be triggered).
+const (
+	backfillPending   = "PENDING"
+	backfillRunning   = "RUNNING"
+	backfillTriggered = "TRIGGERED"
+	backfillSkipped   = "SKIPPED"
+	backfillFailed    = "FAILED"
+)
+
+// Type pageBackfill keeps data required for "Backfill" (/backfill) page.
+type pageBackfill struct {
+	Supported bool
+
+	templates  *templates
+	schedApi   scheduler.API
+	backfiller DagRunBackfiller
+	runJob     jobRunner
+	logger     *slog.Logger
+	config     Config
+
+	jobsMu    sync.Mutex
+	jobs      map[int]*backfillJob
+	jobsOrder []int
+	nextJobId int
+}
+
+// newPageBackfill initialize new state for backfill page.
+func newPageBackfill(
+	schedApi scheduler.API, runJob jobRunner, tmpl *templates,
+	logger *slog.Logger, config Config,
+) *pageBackfill {
+	if logger == nil {
+		logger = defaultLogger()
+	}
+	if config.BackfillConcurrency <= 0 {
+		config.BackfillConcurrency = DefaultConfig.BackfillConcurrency
+	}
+	backfiller, supported := schedApi.(DagRunBackfiller)
+	return &pageBackfill{
+		Supported: supported,
+
+		templates:  tmpl,
+		schedApi:   schedApi,
+		backfiller: backfiller,
+		runJob:     runJob,
+		logger:     logger,
+		config:     config,
+		jobs:       map[int]*backfillJob{},
+		nextJobId:  1,
+	}
+}
+
+// BackfillView represents data rendered on "Backfill" page.
+type BackfillView struct {
+	Page      string
+	Form      backfillForm
+	Supported bool
+	Errors    map[string]string
+	Version   string
+}
+
+// Main handler for "Backfill" page. DAG ID might be preset using dagId query
+// parameter.
+func (pb *pageBackfill) MainHandler(w http.ResponseWriter, r *http.Request) {
+	view := BackfillView{
+		Page: "Backfill",
+		Form: backfillForm{
+			DagId:        r.URL.Query().Get("dagId"),
+			Concurrency:  pb.config.BackfillConcurrency,
+			SkipExisting: true,
+		},
+		Supported: pb.Supported,
+		Errors:    map[string]string{},
+		Version:   Version,
+	}
+	if !pb.Supported {
+		view.Errors[backfillErr] = "Connected Scheduler does not support backfilling DAG runs"
+	}
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	renderErr := pb.templates.Render(w, "page_backfill", view)
+	if renderErr != nil {
+		pb.logger.Error("Cannot render <page_backfill>", "err",
+			renderErr.Error())
+	}
+}
+
+// HTTP handler which computes execution timestamps for backfill based on
+// submitted form and renders the preview.
+func (pb *pageBackfill) PreviewHandler(w http.ResponseWriter, r *http.Request) {
+	preview := backfillPreview{}
+	form, execTs, err := pb.parseBackfillRequest(r)
+	if err != nil {
+		pb.logger.Warn("Invalid backfill input", "form", form, "err",
+			err.Error())
+		preview.Err = err.Error()
+	}
+	preview.Form = form
+	preview.ExecTs = formatBackfillTimestamps(execTs)
+
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	renderErr := pb.templates.Render(w, "backfill_preview", preview)
+	if renderErr != nil {
+		pb.logger.Error("Cannot render <backfill_preview>", "err",
+			renderErr.Error())
+	}
+}
+
+// HTTP handler which starts new backfill job in the background and renders
+// its (initial) progress.
+func (pb *pageBackfill) StartHandler(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	form, execTs, err := pb.parseBackfillRequest(r)
+	if err != nil {
+		pb.logger.Warn("Cannot start backfill - invalid input", "form", form,
+			"err", err.Error())
+		preview := backfillPreview{Form: form, Err: err.Error()}
+		renderErr := pb.templates.Render(w, "backfill_preview", preview)
+		if renderErr != nil {
+			pb.logger.Error("Cannot render <backfill_preview>", "err",
+				renderErr.Error())
+		}
+		return
+	}
+
+	job := pb.newJob(form, execTs)
+	pb.logger.Info("Starting backfill", "jobId", job.id, "dagId", form.DagId,
+		"runs", len(execTs), "concurrency", form.Concurrency)
+	pb.runJob(func(ctx context.Context) {
+		job.run(ctx, pb.backfiller, execTs, pb.logger)
+	})
+
+	renderErr := pb.templates.Render(w, "backfill_job", job.view())
+	if renderErr != nil {
+		pb.logger.Error("Cannot render <backfill_job>", "err",
+			renderErr.Error())
+	}
+}
+
+// HTTP handler which renders the current progress of given backfill job.
+func (pb *pageBackfill) JobHandler(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	jobId, parseErr := getPathValueInt(r, "jobId")
+	if parseErr != nil {
+		pb.logger.Error("Invalid path arguments for backfill JobHandler",
+			"err", parseErr.Error())
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+	pb.jobsMu.Lock()
+	job, exists := pb.jobs[jobId]
+	pb.jobsMu.Unlock()
+	if !exists {
+		pb.logger.Warn("Backfill job does not exist", "jobId", jobId)
+		w.WriteHeader(http.StatusNotFound)
+		return
+	}
+	renderErr := pb.templates.Render(w, "backfill_job", job.view())
+	if renderErr != nil {
+		pb.logger.Error("Cannot render <backfill_job>", "err",
+			renderErr.Error())
+	}
+}
+
+// Parses backfill form from the request and computes execution timestamps.
+func (pb *pageBackfill) parseBackfillRequest(
+	r *http.Request,
+) (backfillForm, []time.Time, error) {
+	var form backfillForm
+	if !pb.Supported {
+		return form, nil, errors.New("connected Scheduler does not support backfilling DAG runs")
+	}
+	if err := r.ParseForm(); err != nil {
+		return form, nil, fmt.Errorf("cannot parse form: %w", err)
+	}
+	form = backfillForm{
+		DagId:        r.FormValue("dagId"),
+		Start:        r.FormValue("start"),
+		End:          r.FormValue("end"),
+		Step:         r.FormValue("step"),
+		Concurrency:  pb.config.BackfillConcurrency,
+		SkipExisting: r.FormValue("skipExisting") != "",
+	}
+	if concStr := r.FormValue("concurrency"); concStr != "" {
+		conc, castErr := strconv.Atoi(concStr)
+		if castErr != nil || conc < 1 || conc > maxBackfillConcurrency {
+			return form, nil, fmt.Errorf("concurrency should be an integer from [1, %d]",
+				maxBackfillConcurrency)
+		}
+		form.Concurrency = conc
+	}
+	execTs, err := form.timestamps(pb.backfiller)
+	return form, execTs, err
+}
+
+// Registers new backfill job. Only maxBackfillJobs latest jobs are kept.
+func (pb *pageBackfill) newJob(form backfillForm, execTs []time.Time) *backfillJob {
+	pb.jobsMu.Lock()
+	defer pb.jobsMu.Unlock()
+
+	items := make([]backfillItem, len(execTs))
+	for i, ts := range execTs {
+		items[i] = backfillItem{
+			ExecTs: ts.Format(backfillDisplayFormat),
+			Status: backfillPending,
+		}
+	}
+	job := &backfillJob{
+		id:           pb.nextJobId,
+		dagId:        form.DagId,
+		concurrency:  form.Concurrency,
+		skipExisting: form.SkipExisting,
+		startedAt:    time.Now(),
+		items:        items,
+	}
+	pb.jobs[job.id] = job
+	pb.jobsOrder = append(pb.jobsOrder, job.id)
+	pb.nextJobId++
+
+	if len(pb.jobsOrder) > maxBackfillJobs {
+		delete(pb.jobs, pb.jobsOrder[0])
+		pb.jobsOrder = pb.jobsOrder[1:]
+	}
+	return job
+}
+
+// Type backfillForm represents user input for backfilling DAG runs. When Step
+// is empty, DAG schedule is used to determine execution timestamps.
+type backfillForm struct {
+	DagId        string
+	Start        string
+	End          string
+	Step         string
+	Concurrency  int
+	SkipExisting bool
+}
+
+// Computes execution timestamps for backfill based on the form.
+func (bf backfillForm) timestamps(b DagRunBackfiller) ([]time.Time, error) {
+	if bf.DagId == "" {
+		return nil, errors.New("DAG ID cannot be empty")
+	}
+	tz := timeutils.CurrentTz()
+	start, startErr := time.ParseInLocation(backfillFormTsFormat, bf.Start, tz)
+	if startErr != nil {
+		return nil, fmt.Errorf("invalid start time (%s)", bf.Start)
+	}
+	end, endErr := time.ParseInLocation(backfillFormTsFormat, bf.End, tz)
+	if endErr != nil {
+		return nil, fmt.Errorf("invalid end time (%s)", bf.End)
+	}
+	if end.Before(start) {
+		return nil, errors.New("end time cannot be before start time")
+	}
+
+	var step time.Duration
+	var sched schedule.Schedule
+	if bf.Step != "" {
+		var parseErr error
+		step, parseErr = time.ParseDuration(bf.Step)
+		if parseErr != nil || step <= 0 {
+			return nil, fmt.Errorf("invalid step (%s), expected positive duration like 30m or 1h",
+				bf.Step)
+		}
+	} else {
+		var schedErr error
+		sched, schedErr = b.DagSchedule(bf.DagId)
+		if schedErr != nil {
+			return nil, fmt.Errorf("cannot get schedule for DAG %s: %w",
+				bf.DagId, schedErr)
+		}
+		if sched == nil {
+			return nil, fmt.Errorf("DAG %s has no schedule, please provide a step",
+				bf.DagId)
+		}
+	}
+	return backfillTimestamps(start, end, step, sched, maxBackfillRuns)
+}
+
+// Computes execution timestamps from [start, end] interval. When step is
+// positive, timestamps are start + k * step, otherwise consecutive points of
+// given schedule are used. Non-nil error is returned, when there would be more
+// than limit timestamps.
+func backfillTimestamps(
+	start, end time.Time, step time.Duration, sched schedule.Schedule,
+	limit int,
+) ([]time.Time, error) {
+	result := make([]time.Time, 0)
+	ts := start
+	if step <= 0 {
+		ts = sched.Next(start.Add(-time.Nanosecond), nil)
+	}
+	for !ts.After(end) {
+		if len(result) == limit {
+			return nil, fmt.Errorf("too many DAG runs, backfill is limited to %d runs",
+				limit)
+		}
+		result = append(result, ts)
+		if step > 0 {
+			ts = ts.Add(step)
+			continue
+		}
+		prev := ts
+		ts = sched.Next(prev, &prev)
+	}
+	return result, nil
+}
+
+func formatBackfillTimestamps(execTs []time.Time) []string {
+	result := make([]string, len(execTs))
+	for i, ts := range execTs {
+		result[i] = ts.Format(backfillDisplayFormat)
+	}
+	return result
+}
+
+// Type backfillPreview represents data for rendering backfill preview.
+type backfillPreview struct {
+	Form   backfillForm
+	ExecTs []string
+	Err    string
+}
+
+// Type backfillJob represents single backfill process which triggers DAG runs
+// in the background.
+type backfillJob struct {
+	mu           sync.Mutex
+	id           int
+	dagId        string
+	concurrency  int
+	skipExisting bool
+	startedAt    time.Time
+	finishedAt   time.Time
+	items        []backfillItem
+}
+
+// Type backfillItem represents state of a single DAG run in backfill job.
+type backfillItem struct {
+	ExecTs string
+	Status string
+	Err    string
+}
+
+// Triggers DAG runs for given execution timestamps using at most
+// job.concurrency parallel requests. DAG runs are no longer triggered, once
+// the context is cancelled.
+func (j *backfillJob) run(
+	ctx context.Context, b DagRunBackfiller, execTs []time.Time,
+	logger *slog.Logger,
+) {
+	forEachConcurrently(len(execTs), j.concurrency, func(i int) {
+		if ctx.Err() != nil {
+			j.setItem(i, backfillFailed, jobCancelledErr)
+			return
+		}
+		j.setItem(i, backfillRunning, "")
+		if j.skipExisting {
+			exists, err := b.DagRunExists(j.dagId, execTs[i])
+			if err != nil {
+				logger.Error("Cannot check if DAG run exists", "dagId",
+					j.dagId, "execTs", execTs[i], "err", err.Error())
+				j.setItem(i, backfillFailed,
+					fmt.Sprintf("cannot check if DAG run exists: %s", err))
+				return
+			}
+			if exists {
+				j.setItem(i, backfillSkipped, "")
+				return
+			}
+		}
+		input := api.DagRunTriggerInput{DagId: j.dagId}
+		if err := b.TriggerDagRunAt(input, execTs[i]); err != nil {
+			logger.Error("Cannot trigger DAG run", "dagId", j.dagId, "execTs",
+				execTs[i], "err", err.Error())
+			j.setItem(i, backfillFailed, err.Error())
+			return
+		}
+		j.setItem(i, backfillTriggered, "")
+	})
+
+	j.mu.Lock()
+	j.finishedAt = time.Now()
+	j.mu.Unlock()
+	logger.Info("Backfill finished", "jobId", j.id, "dagId", j.dagId,
+		"duration", time.Since(j.startedAt))
+}
+
+func (j *backfillJob) setItem(i int, status, errMsg string) {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	j.items[i].Status = status
+	j.items[i].Err = errMsg
+}
+
+// Type backfillJobView is a snapshot of backfillJob state for rendering.
+type backfillJobView struct {
+	Id           int
+	DagId        string
+	Concurrency  int
+	SkipExisting bool
+	Done         bool
+	Finished     int
+	Duration     string
+	Items        []backfillItem
+	Counts       map[string]int
+}
+
+func (j *backfillJob) view() backfillJobView {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+
+	items := make([]backfillItem, len(j.items))
+	copy(items, j.items)
+	counts := map[string]int{}
+	finished := 0
+	for _, item := range items {
+		counts[item.Status]++
+		if item.Status != backfillPending && item.Status != backfillRunning {
+			finished++
+		}
+	}
+	done := !j.finishedAt.IsZero()
+	end := time.Now()
+	if done {
+		end = j.finishedAt
+	}
+	return backfillJobView{
+		Id:           j.id,
+		DagId:        j.dagId,
+		Concurrency:  j.concurrency,
+		SkipExisting: j.skipExisting,
+		Done:         done,
+		Finished:     finished,
+		Duration:     end.Sub(j.startedAt).Round(time.Millisecond).String(),
+		Items:        items,
+		Counts:       counts,
+	}
+}
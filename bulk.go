@@ -0,0 +1,351 @@
+package ui
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/ppacer/core/api"
+	"github.com/ppacer/core/dag"
+	"github.com/ppacer/core/scheduler"
+)
+
+const (
+	// Maximum number of DAG runs in a single bulk action.
+	maxBulkRuns = 500
+
+	// Number of bulk jobs kept in memory.
+	maxBulkJobs = 20
+
+	auditActionRestart = "restart"
+)
+
+// Statuses of a single DAG run in a bulk action.
+const (
+	bulkPending    = "PENDING"
+	bulkRunning    = "RUNNING"
+	bulkRestarted  = "RESTARTED"
+	bulkSkipped    = "SKIPPED"
+	bulkFailed     = "FAILED"
+	bulkWouldStart = "WOULD_RESTART"
+)
+
+// Type pageBulk keeps dependencies required for bulk actions on DAG runs
+// selected on "Runs" and "History" pages.
+type pageBulk struct {
+	templates *templates
+	schedApi  scheduler.API
+	audit     *auditLog
+	runJob    jobRunner
+	logger    *slog.Logger
+	config    Config
+
+	jobsMu    sync.Mutex
+	jobs      map[int]*bulkJob
+	jobsOrder []int
+	nextJobId int
+}
+
+// newPageBulk initialize new state for bulk actions.
+func newPageBulk(
+	schedApi scheduler.API, audit *auditLog, runJob jobRunner,
+	tmpl *templates, logger *slog.Logger, config Config,
+) *pageBulk {
+	if logger == nil {
+		logger = defaultLogger()
+	}
+	return &pageBulk{
+		templates: tmpl,
+		schedApi:  schedApi,
+		audit:     audit,
+		runJob:    runJob,
+		logger:    logger,
+		config:    config,
+		jobs:      map[int]*bulkJob{},
+		nextJobId: 1,
+	}
+}
+
+// Type bulkItem represents state of a single DAG run in a bulk action. Only
+// failed DAG runs can be restarted, other DAG runs are skipped.
+type bulkItem struct {
+	RunId  int64
+	DagId  string
+	ExecTs string
+	Status string
+	Reason string
+	Err    string
+
+	execTsRaw string
+}
+
+// Type bulkPreview represents dry-run of a bulk restart.
+type bulkPreview struct {
+	Items       []bulkItem
+	Restartable int
+	Err         string
+}
+
+// HTTP handler which renders dry-run of restarting selected DAG runs. DAG
+// runs are given by runId form values.
+func (pb *pageBulk) PreviewHandler(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	preview := bulkPreview{}
+	items, err := pb.parseBulkRequest(r)
+	if err != nil {
+		pb.logger.Warn("Invalid bulk restart input", "err", err.Error())
+		preview.Err = err.Error()
+	}
+	for i := range items {
+		if items[i].Status == bulkPending {
+			items[i].Status = bulkWouldStart
+			preview.Restartable++
+		}
+	}
+	preview.Items = items
+	if err := pb.templates.Render(w, "bulk_preview", preview); err != nil {
+		pb.logger.Error("Cannot render <bulk_preview>", "err", err.Error())
+	}
+}
+
+// HTTP handler which starts restarting selected DAG runs in the background
+// and renders the (initial) progress.
+func (pb *pageBulk) RestartHandler(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	items, err := pb.parseBulkRequest(r)
+	if err != nil {
+		pb.logger.Warn("Cannot start bulk restart - invalid input", "err",
+			err.Error())
+		renderErr := pb.templates.Render(w, "bulk_preview",
+			bulkPreview{Err: err.Error()})
+		if renderErr != nil {
+			pb.logger.Error("Cannot render <bulk_preview>", "err",
+				renderErr.Error())
+		}
+		return
+	}
+
+	job := pb.newJob(items, requestUser(r, pb.config.UserHeader))
+	pb.logger.Info("Starting bulk restart", "jobId", job.id, "runs",
+		len(items), "user", job.user)
+	pb.runJob(func(ctx context.Context) {
+		job.run(ctx, pb.schedApi, pb.audit, pb.config.BulkConcurrency,
+			pb.logger)
+	})
+
+	if err := pb.templates.Render(w, "bulk_job", job.view()); err != nil {
+		pb.logger.Error("Cannot render <bulk_job>", "err", err.Error())
+	}
+}
+
+// HTTP handler which renders the current progress of given bulk job.
+func (pb *pageBulk) JobHandler(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	jobId, parseErr := getPathValueInt(r, "jobId")
+	if parseErr != nil {
+		pb.logger.Error("Invalid path arguments for bulk JobHandler", "err",
+			parseErr.Error())
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+	pb.jobsMu.Lock()
+	job, exists := pb.jobs[jobId]
+	pb.jobsMu.Unlock()
+	if !exists {
+		pb.logger.Warn("Bulk job does not exist", "jobId", jobId)
+		w.WriteHeader(http.StatusNotFound)
+		return
+	}
+	if err := pb.templates.Render(w, "bulk_job", job.view()); err != nil {
+		pb.logger.Error("Cannot render <bulk_job>", "err", err.Error())
+	}
+}
+
+// Parses selected DAG run IDs from the request and reads their details. DAG
+// runs which cannot be restarted are marked as skipped.
+func (pb *pageBulk) parseBulkRequest(r *http.Request) ([]bulkItem, error) {
+	if err := r.ParseForm(); err != nil {
+		return nil, fmt.Errorf("cannot parse form: %w", err)
+	}
+	runIds := make([]int64, 0, len(r.Form["runId"]))
+	seen := make(map[int64]struct{})
+	for _, value := range r.Form["runId"] {
+		runId, err := strconv.ParseInt(value, 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid DAG run ID (%s)", value)
+		}
+		if _, exists := seen[runId]; exists {
+			continue
+		}
+		seen[runId] = struct{}{}
+		runIds = append(runIds, runId)
+	}
+	if len(runIds) == 0 {
+		return nil, errors.New("no DAG runs selected")
+	}
+	if len(runIds) > maxBulkRuns {
+		return nil, fmt.Errorf("too many DAG runs selected, bulk actions are limited to %d runs",
+			maxBulkRuns)
+	}
+
+	items := make([]bulkItem, len(runIds))
+	forEachConcurrently(len(runIds), pb.config.BulkConcurrency, func(i int) {
+		items[i] = pb.bulkItem(runIds[i])
+	})
+	return items, nil
+}
+
+// Reads details of given DAG run and prepares bulk item for it.
+func (pb *pageBulk) bulkItem(runId int64) bulkItem {
+	item := bulkItem{RunId: runId, Status: bulkPending}
+	drd, err := pb.schedApi.UIDagrunDetails(int(runId))
+	if err != nil {
+		pb.logger.Error("Cannot read DAG run details for bulk action",
+			"runId", runId, "err", err.Error())
+		item.Status = bulkSkipped
+		item.Reason = "cannot read DAG run details"
+		return item
+	}
+	item.DagId = drd.DagId
+	item.ExecTs = drd.ExecTs.Date + " " + drd.ExecTs.Time
+	item.execTsRaw = drd.ExecTsRaw
+	if drd.Status != dag.RunFailed.String() {
+		item.Status = bulkSkipped
+		item.Reason = fmt.Sprintf("DAG run is %s, only failed runs are restarted",
+			drd.Status)
+	}
+	return item
+}
+
+// Registers new bulk job. Only maxBulkJobs latest jobs are kept.
+func (pb *pageBulk) newJob(items []bulkItem, user string) *bulkJob {
+	pb.jobsMu.Lock()
+	defer pb.jobsMu.Unlock()
+
+	job := &bulkJob{
+		id:        pb.nextJobId,
+		user:      user,
+		startedAt: time.Now(),
+		items:     items,
+	}
+	pb.jobs[job.id] = job
+	pb.jobsOrder = append(pb.jobsOrder, job.id)
+	pb.nextJobId++
+
+	if len(pb.jobsOrder) > maxBulkJobs {
+		delete(pb.jobs, pb.jobsOrder[0])
+		pb.jobsOrder = pb.jobsOrder[1:]
+	}
+	return job
+}
+
+// Type bulkJob represents single bulk restart of DAG runs, running in the
+// background.
+type bulkJob struct {
+	mu         sync.Mutex
+	id         int
+	user       string
+	startedAt  time.Time
+	finishedAt time.Time
+	items      []bulkItem
+}
+
+// Restarts pending DAG runs using at most concurrency parallel requests. Each
+// restart is recorded in the audit log. DAG runs are no longer restarted,
+// once the context is cancelled.
+func (j *bulkJob) run(
+	ctx context.Context, schedApi scheduler.API, audit *auditLog,
+	concurrency int, logger *slog.Logger,
+) {
+	forEachConcurrently(len(j.items), concurrency, func(i int) {
+		j.mu.Lock()
+		item := j.items[i]
+		j.mu.Unlock()
+		if item.Status != bulkPending {
+			return
+		}
+		if ctx.Err() != nil {
+			j.setItem(i, bulkFailed, jobCancelledErr)
+			return
+		}
+		j.setItem(i, bulkRunning, "")
+		input := api.DagRunRestartInput{DagId: item.DagId, ExecTs: item.execTsRaw}
+		err := schedApi.RestartDagRun(input)
+		event := AuditEvent{
+			User:    j.user,
+			Action:  auditActionRestart,
+			RunId:   item.RunId,
+			DagId:   item.DagId,
+			Details: fmt.Sprintf("bulk restart #%d", j.id),
+		}
+		if err != nil {
+			logger.Error("Cannot restart DAG run", "runId", item.RunId, "input",
+				input, "err", err.Error())
+			event.Err = err.Error()
+			audit.add(event)
+			j.setItem(i, bulkFailed, err.Error())
+			return
+		}
+		audit.add(event)
+		j.setItem(i, bulkRestarted, "")
+	})
+
+	j.mu.Lock()
+	j.finishedAt = time.Now()
+	j.mu.Unlock()
+	logger.Info("Bulk restart finished", "jobId", j.id, "duration",
+		time.Since(j.startedAt))
+}
+
+func (j *bulkJob) setItem(i int, status, errMsg string) {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	j.items[i].Status = status
+	j.items[i].Err = errMsg
+}
+
+// Type bulkJobView is a snapshot of bulkJob state for rendering.
+type bulkJobView struct {
+	Id       int
+	User     string
+	Done     bool
+	Finished int
+	Duration string
+	Items    []bulkItem
+	Counts   map[string]int
+}
+
+func (j *bulkJob) view() bulkJobView {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+
+	items := make([]bulkItem, len(j.items))
+	copy(items, j.items)
+	counts := map[string]int{}
+	finished := 0
+	for _, item := range items {
+		counts[item.Status]++
+		if item.Status != bulkPending && item.Status != bulkRunning {
+			finished++
+		}
+	}
+	done := !j.finishedAt.IsZero()
+	end := time.Now()
+	if done {
+		end = j.finishedAt
+	}
+	return bulkJobView{
+		Id:       j.id,
+		User:     j.user,
+		Done:     done,
+		Finished: finished,
+		Duration: end.Sub(j.startedAt).Round(time.Millisecond).String(),
+		Items:    items,
+		Counts:   counts,
+	}
+}
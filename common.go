@@ -1,12 +1,21 @@
 package ui
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
 	"net/http"
 	"strconv"
+	"sync"
+	"time"
+
+	"github.com/ppacer/core/api"
+	"github.com/ppacer/core/timeutils"
 )
 
+// Layout for parsing api.Timestamp date and time fields.
+const timestampLayout = timeutils.UiDateFormat + " " + timeutils.UiTimeDetailedFormat
+
 // Functione encode JSON encodes and writes given object with given status.
 func encode[T any](w http.ResponseWriter, status int, v T) error {
 	w.Header().Set("Content-Type", "application/json")
@@ -48,3 +57,50 @@ func getPathValueStr(r *http.Request, argName string) (string, error) {
 	}
 	return argValue, nil
 }
+
+// Type jobRunner runs jobs started by HTTP handlers, like bulk restarts, in
+// the background. Jobs context is cancelled and jobs are awaited, when the UI
+// is closed.
+type jobRunner func(job func(ctx context.Context))
+
+// Error of background job items, which were not processed before the UI was
+// closed.
+const jobCancelledErr = "cancelled, the UI was closed"
+
+// forEachConcurrently calls fn for every index from [0, n) using at most
+// concurrency goroutines at the same time. It blocks until all calls are
+// finished.
+func forEachConcurrently(n, concurrency int, fn func(i int)) {
+	if concurrency < 1 {
+		concurrency = 1
+	}
+	sem := make(chan struct{}, concurrency)
+	var wg sync.WaitGroup
+	for i := 0; i < n; i++ {
+		wg.Add(1)
+		sem <- struct{}{}
+		go func(idx int) {
+			defer wg.Done()
+			defer func() { <-sem }()
+			fn(idx)
+		}(i)
+	}
+	wg.Wait()
+}
+
+// parseTimestamp parses api.Timestamp back into time.Time. Timestamp keeps
+// only timezone abbreviation, which cannot be reliably resolved, therefore
+// timestamps are parsed in UTC when timezone is UTC and in ppacer timezone
+// otherwise.
+func parseTimestamp(ts api.Timestamp) (time.Time, error) {
+	loc := timeutils.CurrentTz()
+	if ts.Timezone == "UTC" {
+		loc = time.UTC
+	}
+	t, err := time.ParseInLocation(timestampLayout, ts.Date+" "+ts.Time, loc)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("cannot parse timestamp %s %s: %w",
+			ts.Date, ts.Time, err)
+	}
+	return t, nil
+}
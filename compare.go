@@ -0,0 +1,211 @@
+package ui
+
+import (
+	"fmt"
+	"log/slog"
+	"net/http"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/ppacer/core/api"
+	"github.com/ppacer/core/dag"
+	"github.com/ppacer/core/scheduler"
+)
+
+const compareErr = "compareErr"
+
+// Type pageCompare keeps dependencies required for "Compare DAG runs"
+// (/compare) page.
+type pageCompare struct {
+	templates *templates
+	schedApi  scheduler.API
+	logger    *slog.Logger
+}
+
+// newPageCompare initialize new state for DAG runs comparison page.
+func newPageCompare(
+	schedApi scheduler.API, tmpl *templates, logger *slog.Logger,
+) *pageCompare {
+	if logger == nil {
+		logger = defaultLogger()
+	}
+	return &pageCompare{templates: tmpl, schedApi: schedApi, logger: logger}
+}
+
+// DagRunComparison represents comparison of two DAG runs, including their
+// tasks and task configs. Tasks are compared based on their latest attempts.
+type DagRunComparison struct {
+	Page        string
+	A           string
+	B           string
+	RunA        RunSummary
+	RunB        RunSummary
+	Compared    bool
+	Tasks       []TaskComparison
+	OnlyA       []string
+	OnlyB       []string
+	ConfigDiffs []ConfigDiff
+	Errors      map[string]string
+	Version     string
+}
+
+// RunSummary represents summary of a single compared DAG run.
+type RunSummary struct {
+	RunId    int64
+	DagId    string
+	ExecTs   api.Timestamp
+	Status   string
+	Duration string
+	Tasks    int
+	Failed   int
+	Retries  int
+}
+
+// TaskComparison compares the latest attempts of a task in two DAG runs.
+type TaskComparison struct {
+	TaskId        string
+	StatusA       string
+	StatusB       string
+	DurationA     string
+	DurationB     string
+	RetryA        int
+	RetryB        int
+	Delta         string
+	Percent       string
+	Regression    bool
+	Improvement   bool
+	StatusChanged bool
+}
+
+// MainHandler renders comparison of DAG runs given by query parameters a and
+// b. When any of them is missing, only the form for selecting DAG runs is
+// rendered.
+func (pc *pageCompare) MainHandler(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	cmp := DagRunComparison{
+		Page:    "Runs",
+		A:       r.URL.Query().Get("a"),
+		B:       r.URL.Query().Get("b"),
+		Errors:  map[string]string{},
+		Version: Version,
+	}
+	defer func() {
+		if err := pc.templates.Render(w, "page_compare", cmp); err != nil {
+			pc.logger.Error("Cannot render <page_compare>", "err", err.Error())
+		}
+	}()
+	if cmp.A == "" || cmp.B == "" {
+		return
+	}
+
+	runA, errA := strconv.Atoi(cmp.A)
+	runB, errB := strconv.Atoi(cmp.B)
+	if errA != nil || errB != nil {
+		cmp.Errors[compareErr] = "DAG run IDs should be integers"
+		return
+	}
+	drdA, errA := pc.schedApi.UIDagrunDetails(runA)
+	drdB, errB := pc.schedApi.UIDagrunDetails(runB)
+	if errA != nil || errB != nil {
+		pc.logger.Error("Cannot read DAG runs details for comparison", "a",
+			runA, "b", runB, "errA", errA, "errB", errB)
+		cmp.Errors[compareErr] = "Cannot read DAG runs details"
+		return
+	}
+	compareDagRuns(&cmp, drdA, drdB)
+}
+
+// compareDagRuns fills in comparison of given DAG runs.
+func compareDagRuns(cmp *DagRunComparison, drdA, drdB api.UIDagrunDetails) {
+	cmp.Compared = true
+	cmp.RunA, cmp.RunB = runSummary(drdA), runSummary(drdB)
+	if drdA.DagId != drdB.DagId {
+		cmp.Errors[compareErr] = fmt.Sprintf(
+			"Compared DAG runs belong to different DAGs (%s and %s)",
+			drdA.DagId, drdB.DagId)
+	}
+
+	tasksA, tasksB := latestAttempts(drdA.Tasks), latestAttempts(drdB.Tasks)
+	for _, task := range drdA.Tasks {
+		a, ok := tasksA[task.TaskId]
+		if !ok || a.Retry != task.Retry {
+			continue
+		}
+		b, inB := tasksB[task.TaskId]
+		if !inB {
+			cmp.OnlyA = append(cmp.OnlyA, task.TaskId)
+			continue
+		}
+		cmp.Tasks = append(cmp.Tasks, compareTasks(a, b))
+		configA, configB := formatConfig(a.Config), formatConfig(b.Config)
+		lines := diffLines(configA.TextLines(), configB.TextLines())
+		if changes := diffChanges(lines); changes > 0 {
+			cmp.ConfigDiffs = append(cmp.ConfigDiffs, ConfigDiff{
+				TaskId:    task.TaskId,
+				Label:     fmt.Sprintf("run %d", drdB.RunId),
+				BaseLabel: fmt.Sprintf("run %d", drdA.RunId),
+				Lines:     lines,
+				Changes:   changes,
+			})
+		}
+	}
+	for taskId := range tasksB {
+		if _, inA := tasksA[taskId]; !inA {
+			cmp.OnlyB = append(cmp.OnlyB, taskId)
+		}
+	}
+	sort.Strings(cmp.OnlyA)
+	sort.Strings(cmp.OnlyB)
+}
+
+func runSummary(drd api.UIDagrunDetails) RunSummary {
+	summary := RunSummary{
+		RunId:    drd.RunId,
+		DagId:    drd.DagId,
+		ExecTs:   drd.ExecTs,
+		Status:   drd.Status,
+		Duration: drd.Duration,
+	}
+	for _, task := range latestAttempts(drd.Tasks) {
+		summary.Tasks++
+		summary.Retries += task.Retry
+		if task.Status == dag.TaskFailed.String() {
+			summary.Failed++
+		}
+	}
+	return summary
+}
+
+// Returns the latest attempt of each task.
+func latestAttempts(tasks []api.UIDagrunTask) map[string]api.UIDagrunTask {
+	latest := make(map[string]api.UIDagrunTask, len(tasks))
+	for _, task := range tasks {
+		if prev, ok := latest[task.TaskId]; !ok || task.Retry > prev.Retry {
+			latest[task.TaskId] = task
+		}
+	}
+	return latest
+}
+
+func compareTasks(a, b api.UIDagrunTask) TaskComparison {
+	tc := TaskComparison{
+		TaskId:        a.TaskId,
+		StatusA:       a.Status,
+		StatusB:       b.Status,
+		DurationA:     a.Duration,
+		DurationB:     b.Duration,
+		RetryA:        a.Retry,
+		RetryB:        b.Retry,
+		StatusChanged: a.Status != b.Status,
+	}
+	durA, errA := time.ParseDuration(a.Duration)
+	durB, errB := time.ParseDuration(b.Duration)
+	if errA != nil || errB != nil {
+		return tc
+	}
+	delta := durB - durA
+	tc.Delta = formatDurationDelta(delta)
+	tc.Percent, tc.Regression, tc.Improvement = relativeChange(delta, durA)
+	return tc
+}
@@ -4,9 +4,53 @@ package ui
 type Config struct {
 	// Nuber of seconds
 	DagRunsSyncSeconds int
+
+	// Default number of DAG runs triggered concurrently during backfill.
+	BackfillConcurrency int
+
+	// Number of previous DAG runs used to compare DAG run duration.
+	DurationCompareRuns int
+
+	// Number of DAG runs restarted concurrently by bulk actions.
+	BulkConcurrency int
+
+	// Name of HTTP header with name of the user, set by authenticating
+	// reverse proxy. It's used in the audit log. It should be set only when
+	// the UI is served behind a proxy which strips the header from client
+	// requests, otherwise anyone can pretend to be any user. When it's empty
+	// (default) or the header is not set, the user is anonymous.
+	UserHeader string
+
+	// Number of seconds between samples of DAG runs statistics, used for
+	// sparklines and "Stats" page charts. Samples from the last 24 hours are
+	// kept in memory. When it's not positive, the default is used.
+	StatsSampleSeconds int
+
+	// Path of SQLite database file, where the UI persists sampled statistics,
+	// seen DAG runs, audit events and annotations, so they survive UI
+	// restarts. When it's empty, nothing is persisted.
+	StorePath string
+
+	// Number of days records are kept in the store. When it's not positive,
+	// records are never removed.
+	StoreRetentionDays int
+
+	// Alert rules evaluated by the UI every StatsSampleSeconds. Rules can be
+	// also added on "Alerts" page. Invalid rules are logged and skipped.
+	AlertRules []AlertRule
+
+	// URL where changes of alert states are sent as JSON in POST requests.
+	// When it's empty, changes are only logged. Other notifiers can be added
+	// using UI.AddAlertNotifier.
+	AlertWebhookUrl string
 }
 
 // Default UI configuration.
 var DefaultConfig Config = Config{
-	DagRunsSyncSeconds: 2,
+	DagRunsSyncSeconds:  2,
+	BackfillConcurrency: 4,
+	DurationCompareRuns: 10,
+	BulkConcurrency:     4,
+	StatsSampleSeconds:  30,
+	StoreRetentionDays:  30,
 }
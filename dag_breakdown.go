@@ -0,0 +1,326 @@
+package ui
+
+import (
+	"fmt"
+	"log/slog"
+	"math"
+	"net/http"
+	"sort"
+	"time"
+
+	"github.com/ppacer/core/api"
+	"github.com/ppacer/core/dag"
+	"github.com/ppacer/core/scheduler"
+)
+
+const (
+	dagBreakdownErr = "dagBreakdownErr"
+
+	// Maximum number of DAG runs aggregated on "DAG breakdown" page.
+	maxBreakdownRuns = 10000
+
+	// Default time window of "DAG breakdown" page.
+	defaultBreakdownWindow = "24h"
+)
+
+// Columns of DAG breakdown table, which it can be sorted by.
+const (
+	breakdownByDagId       = "dagId"
+	breakdownByTotal       = "total"
+	breakdownBySuccess     = "success"
+	breakdownByFailed      = "failed"
+	breakdownByRunning     = "running"
+	breakdownByScheduled   = "scheduled"
+	breakdownByTasks       = "tasks"
+	breakdownByP50         = "p50"
+	breakdownByP95         = "p95"
+	breakdownByFailureRate = "failureRate"
+)
+
+// Columns of DAG breakdown table in display order.
+var dagBreakdownColumns = []SortColumn{
+	{breakdownByDagId, "DAG ID"},
+	{breakdownByTotal, "Runs"},
+	{breakdownBySuccess, "Success"},
+	{breakdownByFailed, "Failed"},
+	{breakdownByRunning, "Running"},
+	{breakdownByScheduled, "Scheduled"},
+	{breakdownByTasks, "Tasks done"},
+	{breakdownByP50, "p50 duration"},
+	{breakdownByP95, "p95 duration"},
+	{breakdownByFailureRate, "Failure rate"},
+}
+
+// DagBreakdownRow represents DAG runs statistics of a single DAG within a
+// time window. Durations percentiles are computed over finished DAG runs.
+// HasDurations is false, when there are no finished DAG runs. Failure rate is
+// a ratio of failed DAG runs to finished DAG runs, or -1 when there are no
+// finished DAG runs.
+type DagBreakdownRow struct {
+	DagId        string
+	Total        int
+	Counts       api.StatusCounts
+	TasksNum     int
+	TasksDone    int
+	P50          time.Duration
+	P95          time.Duration
+	HasDurations bool
+	FailureRate  float64
+}
+
+// P50Display returns formatted median duration.
+func (r DagBreakdownRow) P50Display() string {
+	if !r.HasDurations {
+		return "-"
+	}
+	return roundDuration(r.P50).String()
+}
+
+// P95Display returns formatted 95th percentile of duration.
+func (r DagBreakdownRow) P95Display() string {
+	if !r.HasDurations {
+		return "-"
+	}
+	return roundDuration(r.P95).String()
+}
+
+// FailureRateDisplay returns failure rate formatted as percent.
+func (r DagBreakdownRow) FailureRateDisplay() string {
+	if r.FailureRate < 0 {
+		return "-"
+	}
+	return fmt.Sprintf("%.1f%%", r.FailureRate*100)
+}
+
+// buildDagBreakdown aggregates given DAG runs per DAG. Rows are ordered by
+// DAG ID.
+func buildDagBreakdown(dagruns api.UIDagrunList) []DagBreakdownRow {
+	rows := make(map[string]*DagBreakdownRow)
+	durations := make(map[string][]time.Duration)
+	for _, run := range dagruns {
+		row, exists := rows[run.DagId]
+		if !exists {
+			row = &DagBreakdownRow{DagId: run.DagId}
+			rows[run.DagId] = row
+		}
+		row.Total++
+		row.TasksNum += run.TaskNum
+		row.TasksDone += run.TaskCompletedNum
+		finished := false
+		switch run.Status {
+		case dag.RunSuccess.String():
+			row.Counts.Success++
+			finished = true
+		case dag.RunFailed.String():
+			row.Counts.Failed++
+			finished = true
+		case dag.RunRunning.String():
+			row.Counts.Running++
+		case dag.RunScheduled.String(), dag.RunReadyToSchedule.String():
+			row.Counts.Scheduled++
+		}
+		if !finished {
+			continue
+		}
+		if d, err := time.ParseDuration(run.Duration); err == nil {
+			durations[run.DagId] = append(durations[run.DagId], d)
+		}
+	}
+
+	result := make([]DagBreakdownRow, 0, len(rows))
+	for dagId, row := range rows {
+		row.FailureRate = -1
+		if finished := row.Counts.Success + row.Counts.Failed; finished > 0 {
+			row.FailureRate = float64(row.Counts.Failed) / float64(finished)
+		}
+		if ds := durations[dagId]; len(ds) > 0 {
+			sort.Slice(ds, func(i, j int) bool { return ds[i] < ds[j] })
+			row.P50 = percentileDuration(ds, 50)
+			row.P95 = percentileDuration(ds, 95)
+			row.HasDurations = true
+		}
+		result = append(result, *row)
+	}
+	sort.Slice(result, func(i, j int) bool {
+		return result[i].DagId < result[j].DagId
+	})
+	return result
+}
+
+// Returns p-th percentile of sorted durations using nearest-rank method.
+func percentileDuration(sorted []time.Duration, p int) time.Duration {
+	rank := int(math.Ceil(float64(p) / 100 * float64(len(sorted))))
+	return sorted[max(rank, 1)-1]
+}
+
+// Sorts DAG breakdown rows by given column. Rows without durations or
+// failure rate are considered the smallest. Ties are ordered by DAG ID.
+func sortDagBreakdown(rows []DagBreakdownRow, column string, desc bool) {
+	value := func(r DagBreakdownRow) float64 {
+		switch column {
+		case breakdownByTotal:
+			return float64(r.Total)
+		case breakdownBySuccess:
+			return float64(r.Counts.Success)
+		case breakdownByFailed:
+			return float64(r.Counts.Failed)
+		case breakdownByRunning:
+			return float64(r.Counts.Running)
+		case breakdownByScheduled:
+			return float64(r.Counts.Scheduled)
+		case breakdownByTasks:
+			return float64(r.TasksDone)
+		case breakdownByP50:
+			if !r.HasDurations {
+				return -1
+			}
+			return float64(r.P50)
+		case breakdownByP95:
+			if !r.HasDurations {
+				return -1
+			}
+			return float64(r.P95)
+		case breakdownByFailureRate:
+			return r.FailureRate
+		default:
+			return 0
+		}
+	}
+	sort.SliceStable(rows, func(i, j int) bool {
+		a, b := rows[i], rows[j]
+		if desc {
+			a, b = b, a
+		}
+		if column == breakdownByDagId {
+			return a.DagId < b.DagId
+		}
+		va, vb := value(a), value(b)
+		if va == vb {
+			return rows[i].DagId < rows[j].DagId
+		}
+		return va < vb
+	})
+}
+
+// Type pageDagBreakdown keeps data required for "DAG breakdown"
+// (/stats/dags) page.
+type pageDagBreakdown struct {
+	templates *templates
+	schedApi  scheduler.API
+	runs      *dagRunSource
+	logger    *slog.Logger
+}
+
+func newPageDagBreakdown(
+	schedApi scheduler.API, runs *dagRunSource, tmpl *templates,
+	logger *slog.Logger,
+) *pageDagBreakdown {
+	if logger == nil {
+		logger = defaultLogger()
+	}
+	return &pageDagBreakdown{
+		templates: tmpl,
+		schedApi:  schedApi,
+		runs:      runs,
+		logger:    logger,
+	}
+}
+
+// DagBreakdownView represents data rendered on "DAG breakdown" page.
+// Truncated is set, when more DAG runs than maxBreakdownRuns were executed
+// within the window. Scanned is set, when Scheduler client does not implement
+// DagRunHistory and only the latest dagRunScanLimit DAG runs and DAG runs
+// saved in the history store are aggregated.
+type DagBreakdownView struct {
+	Page      string
+	Window    string
+	Windows   []string
+	Sort      string
+	Desc      bool
+	Columns   []SortColumn
+	Rows      []DagBreakdownRow
+	RunsNum   int
+	Truncated bool
+	Scanned   bool
+	Errors    map[string]string
+	Version   string
+}
+
+// SortLink returns URL of the page sorted by given column. Clicking already
+// selected column reverses the order.
+func (v DagBreakdownView) SortLink(column string) string {
+	desc := column != breakdownByDagId
+	if column == v.Sort {
+		desc = !v.Desc
+	}
+	link := fmt.Sprintf("/stats/dags?window=%s&sort=%s", v.Window, column)
+	if desc {
+		link += "&desc=1"
+	}
+	return link
+}
+
+// SortMark returns an arrow indicating sorting order, when the table is
+// sorted by given column.
+func (v DagBreakdownView) SortMark(column string) string {
+	if column != v.Sort {
+		return ""
+	}
+	if v.Desc {
+		return "▼"
+	}
+	return "▲"
+}
+
+// Main handler for "DAG breakdown" page. It aggregates DAG runs executed
+// within time window given by window query parameter per DAG. Table is sorted
+// by sort and desc query parameters.
+func (pb *pageDagBreakdown) MainHandler(w http.ResponseWriter, r *http.Request) {
+	values := r.URL.Query()
+	view := DagBreakdownView{
+		Page:    "Runs",
+		Window:  defaultBreakdownWindow,
+		Sort:    breakdownByFailed,
+		Desc:    true,
+		Columns: dagBreakdownColumns,
+		Errors:  map[string]string{},
+		Version: Version,
+	}
+	for _, win := range dagRunsWindows {
+		view.Windows = append(view.Windows, win.Name)
+	}
+	if _, ok := windowDuration(values.Get("window")); ok {
+		view.Window = values.Get("window")
+	}
+	for _, col := range dagBreakdownColumns {
+		if col.Key == values.Get("sort") {
+			view.Sort = col.Key
+			view.Desc = values.Get("desc") == "1"
+		}
+	}
+
+	window, _ := windowDuration(view.Window)
+	q := DagRunQuery{
+		Since: time.Now().Add(-window),
+		Limit: maxBreakdownRuns,
+	}
+	dagruns, err := pb.runs.query(q)
+	if err != nil {
+		pb.logger.Error("Cannot read DAG runs for DAG breakdown", "window",
+			view.Window, "err", err.Error())
+		view.Errors[dagBreakdownErr] = "Cannot read DAG runs: " + err.Error()
+	}
+	view.RunsNum = len(dagruns)
+	view.Truncated = len(dagruns) == maxBreakdownRuns
+	_, hasHistory := pb.schedApi.(DagRunHistory)
+	view.Scanned = !hasHistory
+	view.Rows = buildDagBreakdown(dagruns)
+	sortDagBreakdown(view.Rows, view.Sort, view.Desc)
+
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	renderErr := pb.templates.Render(w, "page_dag_breakdown", view)
+	if renderErr != nil {
+		pb.logger.Error("Cannot render <page_dag_breakdown>", "err",
+			renderErr.Error())
+	}
+}
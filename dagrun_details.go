@@ -7,6 +7,7 @@ import (
 	"sort"
 	"strconv"
 	"strings"
+	"time"
 
 	"github.com/ppacer/core/api"
 	"github.com/ppacer/core/scheduler"
@@ -27,14 +28,21 @@ type pageDagRunDetails struct {
 	Errors  map[string]string
 	Version string
 
-	templates *templates
-	schedApi  scheduler.API
-	logger    *slog.Logger
+	templates   *templates
+	schedApi    scheduler.API
+	runs        *dagRunSource
+	index       *runIndex
+	audit       *auditLog
+	annotations *annotationStore
+	logger      *slog.Logger
+	config      Config
 }
 
 // newPageDagRunDetails initialize new state for DAG run details page.
 func newPageDagRunDetails(
-	schedApi scheduler.API, tmpl *templates, logger *slog.Logger,
+	schedApi scheduler.API, runs *dagRunSource, index *runIndex,
+	audit *auditLog, annotations *annotationStore, tmpl *templates,
+	logger *slog.Logger, config Config,
 ) *pageDagRunDetails {
 	if logger == nil {
 		logger = defaultLogger()
@@ -44,9 +52,14 @@ func newPageDagRunDetails(
 		Errors:  map[string]string{},
 		Version: Version,
 
-		templates: tmpl,
-		schedApi:  schedApi,
-		logger:    logger,
+		templates:   tmpl,
+		schedApi:    schedApi,
+		runs:        runs,
+		index:       index,
+		audit:       audit,
+		annotations: annotations,
+		logger:      logger,
+		config:      config,
 	}
 }
 
@@ -77,6 +90,24 @@ func (pdrd *pageDagRunDetails) MainHandler(w http.ResponseWriter, r *http.Reques
 		pdrd.Errors[dagrunDetailsErr] = msg
 	}
 	pdrd.Details = pdrd.prepareDagrunTaskDetails(drd, maxTaskIndent)
+	pdrd.Details.Actions = pdrd.dagrunActions(pdrd.Details)
+	pdrd.Details.Annotations = pdrd.annotations.list(pdrd.Details.RunId)
+	pdrd.Details.Graph = buildDagrunGraph(
+		pdrd.Details.Tasks, pdrd.dagTaskParents(drd.DagId), maxGraphTasks,
+	)
+	pdrd.Details.Gantt = buildDagrunGantt(pdrd.Details.Tasks, time.Now())
+	pdrd.applyLogsFilterFromUrl(r.URL.Query())
+	if err == nil {
+		pdrd.index.addDagrun(drd)
+		cmp, cmpErr := dagrunDurationComparison(
+			pdrd.runs, drd, pdrd.config.DurationCompareRuns,
+		)
+		if cmpErr != nil {
+			pdrd.logger.Warn("Cannot compare DAG run duration with previous runs",
+				"runId", runId, "dagId", drd.DagId, "err", cmpErr.Error())
+		}
+		pdrd.Details.DurationCmp = cmp
+	}
 
 	w.Header().Set("Content-Type", "text/html; charset=utf-8")
 	renderErr := pdrd.templates.Render(w, "page_dagrun_details", pdrd)
@@ -111,6 +142,17 @@ func (pdrd *pageDagRunDetails) RestartDagRunHandler(w http.ResponseWriter, r *ht
 	pdrd.logger.Info("Restarting DAG run", "input", input)
 
 	err := pdrd.schedApi.RestartDagRun(input)
+	runIdInt, _ := strconv.ParseInt(runId, 10, 64)
+	event := AuditEvent{
+		User:   requestUser(r, pdrd.config.UserHeader),
+		Action: auditActionRestart,
+		RunId:  runIdInt,
+		DagId:  dagId,
+	}
+	if err != nil {
+		event.Err = err.Error()
+	}
+	pdrd.audit.add(event)
 	if err != nil {
 		pdrd.logger.Error("Error while restarting DAG run", "input", input,
 			"err", err.Error())
@@ -178,7 +220,9 @@ func (pdrd *pageDagRunDetails) RefreshSingleTaskDetailsHandler(
 		Config:         taskDetails.Config,
 		TaskLogs:       toTaskLogs(taskDetails.TaskLogs),
 		LogsWindowOpen: true,
+		LogsPaginated:  pdrd.logsPaginated(),
 	}
+	pdrd.setTaskActions([]DagrunTask{drt})
 
 	w.Header().Set("Content-Type", "text/html; charset=utf-8")
 	renderErr := pdrd.templates.Render(w, "dagrun_details_task_item", drt)
@@ -188,22 +232,31 @@ func (pdrd *pageDagRunDetails) RefreshSingleTaskDetailsHandler(
 	}
 }
 
-func parseTaskLogsArgs(r *http.Request) (int, string, int, TaskPos, error) {
-	var taskPos TaskPos
+// parseTaskAttemptArgs parses runId, taskId and retry path arguments.
+func parseTaskAttemptArgs(r *http.Request) (int, string, int, error) {
 	runId, parseRunIdErr := getPathValueInt(r, "runId")
 	if parseRunIdErr != nil {
 		err := fmt.Errorf("invalid runId: %w", parseRunIdErr)
-		return -1, "", -1, taskPos, err
+		return -1, "", -1, err
 	}
 	taskId, parseTaskIdErr := getPathValueStr(r, "taskId")
 	if parseTaskIdErr != nil {
 		err := fmt.Errorf("invalid taskId: %w", parseTaskIdErr)
-		return -1, "", -1, taskPos, err
+		return -1, "", -1, err
 	}
 	retry, parseRetryErr := getPathValueInt(r, "retry")
 	if parseRetryErr != nil {
 		err := fmt.Errorf("invalid retry argument: %w", parseRetryErr)
-		return -1, "", -1, taskPos, err
+		return -1, "", -1, err
+	}
+	return runId, taskId, retry, nil
+}
+
+func parseTaskLogsArgs(r *http.Request) (int, string, int, TaskPos, error) {
+	var taskPos TaskPos
+	runId, taskId, retry, attemptErr := parseTaskAttemptArgs(r)
+	if attemptErr != nil {
+		return -1, "", -1, taskPos, attemptErr
 	}
 
 	taskPosStr, tpErr := getPathValueStr(r, "taskPos")
@@ -214,7 +267,7 @@ func parseTaskLogsArgs(r *http.Request) (int, string, int, TaskPos, error) {
 	const taskPosFields = 3
 	taskPosSplit := strings.Split(taskPosStr, "_")
 	if len(taskPosSplit) != taskPosFields {
-		err := fmt.Errorf("invalid taskPos value, expected %d_%d_%d format")
+		err := fmt.Errorf("invalid taskPos value, expected %%d_%%d_%%d format")
 		return -1, "", -1, taskPos, err
 	}
 	var posValues [taskPosFields]int
@@ -237,6 +290,13 @@ func parseTaskLogsArgs(r *http.Request) (int, string, int, TaskPos, error) {
 func (pdrd *pageDagRunDetails) prepareDagrunTaskDetails(
 	drd api.UIDagrunDetails, maxIndent int,
 ) DagrunDetails {
+	tasks := prepareDagrunTasks(drd.RunId, drd.Tasks, maxIndent)
+	logsPaginated := pdrd.logsPaginated()
+	for i := range tasks {
+		tasks[i].LogsPaginated = logsPaginated
+	}
+	setOtherRetries(tasks)
+	pdrd.setTaskActions(tasks)
 	return DagrunDetails{
 		RunId:     drd.RunId,
 		DagId:     drd.DagId,
@@ -244,10 +304,32 @@ func (pdrd *pageDagRunDetails) prepareDagrunTaskDetails(
 		ExecTsRaw: drd.ExecTsRaw,
 		Status:    drd.Status,
 		Duration:  drd.Duration,
-		Tasks:     prepareDagrunTasks(drd.RunId, drd.Tasks, maxIndent),
+		Tasks:     tasks,
 	}
 }
 
+// Checks if Scheduler client supports loading more task log records.
+func (pdrd *pageDagRunDetails) logsPaginated() bool {
+	_, ok := pdrd.schedApi.(TaskLogsReader)
+	return ok
+}
+
+// Reads DAG structure, if Scheduler client supports it. Otherwise nil is
+// returned and DAG run graph is drawn without edges.
+func (pdrd *pageDagRunDetails) dagTaskParents(dagId string) map[string][]string {
+	graphApi, ok := pdrd.schedApi.(DagTaskGraph)
+	if !ok || dagId == "" {
+		return nil
+	}
+	parents, err := graphApi.DagTaskParents(dagId)
+	if err != nil {
+		pdrd.logger.Warn("Cannot read DAG task parents", "dagId", dagId,
+			"err", err.Error())
+		return nil
+	}
+	return parents
+}
+
 func (pdrd *pageDagRunDetails) cleanDagrunDetailsErr() {
 	if _, exist := pdrd.Errors[dagrunDetailsErr]; exist {
 		pdrd.Errors[dagrunDetailsErr] = ""
@@ -334,6 +416,13 @@ type DagrunDetails struct {
 	Status    string
 	Duration  string
 	Tasks     []DagrunTask
+	Graph     DagrunGraph
+	Gantt     DagrunGantt
+	Actions   DagrunActions
+
+	Annotations []Annotation
+
+	DurationCmp DurationComparison
 }
 
 type DagrunTask struct {
@@ -346,8 +435,13 @@ type DagrunTask struct {
 	Pos            TaskPos
 	Duration       string
 	Config         string
+	OtherRetries   []int
 	TaskLogs       TaskLogs
 	LogsWindowOpen bool
+	LogsPaginated  bool
+	LogsFilter     *TaskLogsFiltered
+	CanClear       bool
+	CanMark        bool
 	Errors         map[string]string
 }
 
@@ -364,6 +458,7 @@ type TaskPos struct {
 type TaskLogs struct {
 	LogRecordsCount int
 	LoadedRecords   int
+	Offset          int
 	Records         []TaskLogRecord
 }
 
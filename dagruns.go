@@ -4,7 +4,7 @@ import (
 	"fmt"
 	"log/slog"
 	"net/http"
-	"strconv"
+	"time"
 
 	"github.com/ppacer/core/api"
 	"github.com/ppacer/core/scheduler"
@@ -18,19 +18,26 @@ const (
 type pageDagRuns struct {
 	Page          string
 	Stats         api.UIDagrunStats
+	Sparklines    map[string]Sparkline
 	LatestDagRuns api.UIDagrunList
 	DagRunsNum    int
+	Filter        DagRunsFilter
+	PausedDags    []DagState
 	SyncSeconds   int
 	Errors        map[string]string
 	Version       string
 
 	templates *templates
 	schedApi  scheduler.API
+	runs      *dagRunSource
+	index     *runIndex
+	history   *statsHistory
 	logger    *slog.Logger
 }
 
 func newPageDagRuns(
-	schedApi scheduler.API, tmpl *templates, logger *slog.Logger,
+	schedApi scheduler.API, runs *dagRunSource, index *runIndex,
+	history *statsHistory, tmpl *templates, logger *slog.Logger,
 	config Config,
 ) *pageDagRuns {
 	if logger == nil {
@@ -39,21 +46,25 @@ func newPageDagRuns(
 	return &pageDagRuns{
 		Page:        "Runs",
 		DagRunsNum:  10,
+		Filter:      DagRunsFilter{Num: 10},
 		SyncSeconds: config.DagRunsSyncSeconds,
 		Errors:      map[string]string{},
 		Version:     Version,
 
 		templates: tmpl,
 		schedApi:  schedApi,
+		runs:      runs,
+		index:     index,
+		history:   history,
 		logger:    logger,
 	}
 }
 
-// Main handler for "Runs" page.
-func (pdr *pageDagRuns) MainHandler(w http.ResponseWriter, _ *http.Request) {
+// Main handler for "Runs" page. The latest DAG runs list is filtered and
+// sorted based on URL query parameters.
+func (pdr *pageDagRuns) MainHandler(w http.ResponseWriter, r *http.Request) {
 	w.Header().Set("Content-Type", "text/html; charset=utf-8")
-	pdr.cleanStatsErr()
-	pdr.cleanListErr()
+	pdr = pdr.withFilter(r)
 	statsErr := pdr.syncCurrentStats()
 	if statsErr != nil {
 		msg := "Error while getting current DAG runs stats"
@@ -64,10 +75,11 @@ func (pdr *pageDagRuns) MainHandler(w http.ResponseWriter, _ *http.Request) {
 	listErr := pdr.syncLatestDagRuns()
 	if listErr != nil {
 		msg := "Error while getting latest DAG runs"
-		pdr.logger.Error(msg, "n", pdr.DagRunsNum, "err", listErr.Error())
+		pdr.logger.Error(msg, "filter", pdr.Filter, "err", listErr.Error())
 		pdr.Errors[dagrunListErrorKey] = fmt.Sprintf("%s: %s", msg,
 			listErr.Error())
 	}
+	pdr.syncPausedDags()
 	renderErr := pdr.templates.Render(w, "page_dagruns", pdr)
 	if renderErr != nil {
 		pdr.logger.Error("Cannot render <page_dagruns>", "err",
@@ -78,7 +90,7 @@ func (pdr *pageDagRuns) MainHandler(w http.ResponseWriter, _ *http.Request) {
 // HTTP handler which refresh DAG runs statistics and render related component.
 func (pdr *pageDagRuns) StatsHandler(w http.ResponseWriter, r *http.Request) {
 	w.Header().Set("Content-Type", "text/html; charset=utf-8")
-	pdr.cleanStatsErr()
+	pdr = pdr.withFilter(r)
 	statsErr := pdr.syncCurrentStats()
 	if statsErr != nil {
 		msg := "Error while getting current DAG runs stats"
@@ -92,23 +104,30 @@ func (pdr *pageDagRuns) StatsHandler(w http.ResponseWriter, r *http.Request) {
 	}
 }
 
-func (pdr *pageDagRuns) UpdateDagRunsNumHandler(
-	w http.ResponseWriter, r *http.Request,
-) {
-	if err := r.ParseForm(); err != nil {
-		pdr.logger.Error("Cannot parse form with DagRunsNum", "err",
-			err.Error())
+// Returns copy of the page state with the latest DAG runs list filter parsed
+// from the request. Handlers render from the copy, so concurrent requests do
+// not affect each other's stats, errors and filters.
+func (pdr *pageDagRuns) withFilter(r *http.Request) *pageDagRuns {
+	page := *pdr
+	page.Errors = map[string]string{}
+	page.Filter = parseDagRunsFilter(r.URL.Query(), pdr.DagRunsNum)
+	page.DagRunsNum = page.Filter.Num
+	return &page
+}
+
+// Reads paused DAGs, when Scheduler client supports pausing DAGs. Errors are
+// only logged, because paused DAGs banner is not essential for the page.
+func (pdr *pageDagRuns) syncPausedDags() {
+	pauser, ok := pdr.schedApi.(DagPauser)
+	if !ok {
 		return
 	}
-
-	numStr := r.FormValue("num")
-	num, err := strconv.Atoi(numStr)
+	states, err := pauser.UIDagStates()
 	if err != nil {
-		pdr.logger.Error("Cannot cast given value into number", "numStr",
-			numStr, "err", err.Error())
+		pdr.logger.Warn("Cannot read DAG states", "err", err.Error())
 		return
 	}
-	pdr.DagRunsNum = num
+	pdr.PausedDags = pausedDags(states)
 }
 
 // SetSyncSeconds returns a HTTP handler for setting SyncSeconds.
@@ -120,13 +139,14 @@ func (pdr *pageDagRuns) SetSyncSeconds(seconds int) http.HandlerFunc {
 }
 
 // HTTP handler which refresh latest DAG runs list and render related component.
-func (pdr *pageDagRuns) ListHandler(w http.ResponseWriter, _ *http.Request) {
+// The list is filtered and sorted based on URL query parameters.
+func (pdr *pageDagRuns) ListHandler(w http.ResponseWriter, r *http.Request) {
 	w.Header().Set("Content-Type", "text/html; charset=utf-8")
-	pdr.cleanListErr()
+	pdr = pdr.withFilter(r)
 	listErr := pdr.syncLatestDagRuns()
 	if listErr != nil {
 		msg := "Error while getting latest DAG runs list"
-		pdr.logger.Error(msg, "n", pdr.DagRunsNum, "err", listErr.Error())
+		pdr.logger.Error(msg, "filter", pdr.Filter, "err", listErr.Error())
 		pdr.Errors[dagrunListErrorKey] = fmt.Sprintf("%s: %s", msg,
 			listErr.Error())
 	}
@@ -142,26 +162,17 @@ func (pdr *pageDagRuns) syncCurrentStats() error {
 		return err
 	}
 	pdr.Stats = currentStats
+	since := time.Now().Add(-statsHistoryWindow)
+	pdr.Sparklines = buildSparklines(pdr.history.since(since))
 	return nil
 }
 
 func (pdr *pageDagRuns) syncLatestDagRuns() error {
-	dagruns, err := pdr.schedApi.UIDagrunLatest(pdr.DagRunsNum)
+	dagruns, err := filteredDagRuns(pdr.schedApi, pdr.runs, pdr.Filter, time.Now())
 	if err != nil {
 		return err
 	}
 	pdr.LatestDagRuns = dagruns
+	pdr.index.addRuns(dagruns)
 	return nil
 }
-
-func (pdr *pageDagRuns) cleanStatsErr() {
-	if _, exist := pdr.Errors[dagrunStatsErrorKey]; exist {
-		pdr.Errors[dagrunStatsErrorKey] = ""
-	}
-}
-
-func (pdr *pageDagRuns) cleanListErr() {
-	if _, exist := pdr.Errors[dagrunListErrorKey]; exist {
-		pdr.Errors[dagrunListErrorKey] = ""
-	}
-}
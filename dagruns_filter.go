@@ -0,0 +1,247 @@
+package ui
+
+import (
+	"net/url"
+	"path"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/ppacer/core/api"
+	"github.com/ppacer/core/dag"
+	"github.com/ppacer/core/scheduler"
+)
+
+const (
+	sortByRunId    = "runId"
+	sortByDagId    = "dagId"
+	sortByExecTs   = "execTs"
+	sortByStatus   = "status"
+	sortByDuration = "duration"
+	sortByProgress = "progress"
+)
+
+// Page sizes available on the latest DAG runs list.
+var dagRunsPageSizes = []int{5, 10, 25, 50}
+
+// DAG run statuses which can be toggled on the latest DAG runs list.
+var dagRunStatuses = []string{
+	dag.RunReadyToSchedule.String(),
+	dag.RunScheduled.String(),
+	dag.RunRunning.String(),
+	dag.RunSuccess.String(),
+	dag.RunFailed.String(),
+}
+
+// Time windows available on the latest DAG runs list.
+var dagRunsWindows = []struct {
+	Name     string
+	Duration time.Duration
+}{
+	{"1h", time.Hour},
+	{"6h", 6 * time.Hour},
+	{"24h", 24 * time.Hour},
+	{"7d", 7 * 24 * time.Hour},
+	{"30d", 30 * 24 * time.Hour},
+}
+
+// SortColumn represents a column which the latest DAG runs list can be sorted
+// by.
+type SortColumn struct {
+	Key   string
+	Label string
+}
+
+// Columns which the latest DAG runs list can be sorted by.
+var dagRunsSortColumns = []SortColumn{
+	{sortByRunId, "Run ID"},
+	{sortByDagId, "DAG ID"},
+	{sortByExecTs, "Execution Time"},
+	{sortByStatus, "Status"},
+	{sortByDuration, "Duration"},
+	{sortByProgress, "Progress"},
+}
+
+// DagRunsFilter represents filters and sorting of the latest DAG runs list.
+// DagId is a prefix of DAG ID or a glob pattern, when it contains any of *, ?
+// or [ characters. Window limits DAG runs to those executed within the last
+// given period. Empty Sort means the order returned by the Scheduler.
+type DagRunsFilter struct {
+	Statuses []string
+	DagId    string
+	Window   string
+	Sort     string
+	Desc     bool
+	Num      int
+}
+
+// parseDagRunsFilter parses the latest DAG runs list filter from URL query
+// values. Invalid values are ignored.
+func parseDagRunsFilter(values url.Values, defaultNum int) DagRunsFilter {
+	filter := DagRunsFilter{
+		DagId: strings.TrimSpace(values.Get("dag")),
+		Num:   defaultNum,
+		Desc:  values.Get("desc") == "1",
+	}
+	for _, status := range values["status"] {
+		if slices.Contains(dagRunStatuses, status) {
+			filter.Statuses = append(filter.Statuses, status)
+		}
+	}
+	if _, ok := windowDuration(values.Get("window")); ok {
+		filter.Window = values.Get("window")
+	}
+	for _, col := range dagRunsSortColumns {
+		if col.Key == values.Get("sort") {
+			filter.Sort = col.Key
+		}
+	}
+	if num, err := strconv.Atoi(values.Get("num")); err == nil &&
+		slices.Contains(dagRunsPageSizes, num) {
+		filter.Num = num
+	}
+	return filter
+}
+
+// Active checks if any filter, other than page size and sorting, is set.
+func (f DagRunsFilter) Active() bool {
+	return len(f.Statuses) > 0 || f.DagId != "" || f.Window != ""
+}
+
+// StatusOn checks if given status is selected.
+func (f DagRunsFilter) StatusOn(status string) bool {
+	return slices.Contains(f.Statuses, status)
+}
+
+// AllStatuses returns DAG run statuses which can be selected.
+func (f DagRunsFilter) AllStatuses() []string { return dagRunStatuses }
+
+// PageSizes returns available page sizes.
+func (f DagRunsFilter) PageSizes() []int { return dagRunsPageSizes }
+
+// Windows returns names of available time windows.
+func (f DagRunsFilter) Windows() []string {
+	names := make([]string, len(dagRunsWindows))
+	for i, w := range dagRunsWindows {
+		names[i] = w.Name
+	}
+	return names
+}
+
+// SortColumns returns columns which DAG runs can be sorted by.
+func (f DagRunsFilter) SortColumns() []SortColumn { return dagRunsSortColumns }
+
+// matchesDagId checks if given DAG ID matches the filter.
+func (f DagRunsFilter) matchesDagId(dagId string) bool {
+	if f.DagId == "" {
+		return true
+	}
+	if strings.ContainsAny(f.DagId, "*?[") {
+		matched, err := path.Match(f.DagId, dagId)
+		return err == nil && matched
+	}
+	return strings.HasPrefix(dagId, f.DagId)
+}
+
+func windowDuration(name string) (time.Duration, bool) {
+	for _, w := range dagRunsWindows {
+		if w.Name == name {
+			return w.Duration, true
+		}
+	}
+	return 0, false
+}
+
+// filteredDagRuns lists the latest DAG runs matching the filter and sorts
+// them. Without active filters the latest DAG runs are read directly.
+func filteredDagRuns(
+	schedApi scheduler.API, runs *dagRunSource, filter DagRunsFilter,
+	now time.Time,
+) (api.UIDagrunList, error) {
+	var dagruns api.UIDagrunList
+	var err error
+	if !filter.Active() {
+		dagruns, err = schedApi.UIDagrunLatest(filter.Num)
+	} else {
+		dagruns, err = queryFilteredDagRuns(runs, filter, now)
+	}
+	if err != nil {
+		return nil, err
+	}
+	sortDagRuns(dagruns, filter.Sort, filter.Desc)
+	return dagruns, nil
+}
+
+func queryFilteredDagRuns(
+	runs *dagRunSource, filter DagRunsFilter, now time.Time,
+) (api.UIDagrunList, error) {
+	q := DagRunQuery{Statuses: filter.Statuses, Limit: filter.Num}
+	if window, ok := windowDuration(filter.Window); ok {
+		q.Since = now.Add(-window)
+	}
+	if filter.DagId != "" {
+		// DAG ID prefix and patterns are matched on the UI side.
+		q.Limit = dagRunScanLimit
+	}
+	candidates, err := runs.query(q)
+	if err != nil {
+		return nil, err
+	}
+	dagruns := make(api.UIDagrunList, 0, filter.Num)
+	for _, row := range candidates {
+		if !filter.matchesDagId(row.DagId) {
+			continue
+		}
+		dagruns = append(dagruns, row)
+		if len(dagruns) == filter.Num {
+			break
+		}
+	}
+	return dagruns, nil
+}
+
+// Sorts DAG runs by given column. Rows which values cannot be parsed (like
+// duration of running DAG runs) are considered the greatest.
+func sortDagRuns(dagruns api.UIDagrunList, column string, desc bool) {
+	if column == "" {
+		return
+	}
+	less := func(a, b api.UIDagrunRow) bool {
+		switch column {
+		case sortByDagId:
+			return a.DagId < b.DagId
+		case sortByExecTs:
+			ta, _ := parseTimestamp(a.ExecTs)
+			tb, _ := parseTimestamp(b.ExecTs)
+			return ta.Before(tb)
+		case sortByStatus:
+			return a.Status < b.Status
+		case sortByDuration:
+			da, errA := time.ParseDuration(a.Duration)
+			db, errB := time.ParseDuration(b.Duration)
+			if errA != nil || errB != nil {
+				return errA == nil
+			}
+			return da < db
+		case sortByProgress:
+			return progress(a) < progress(b)
+		default:
+			return a.RunId < b.RunId
+		}
+	}
+	sort.SliceStable(dagruns, func(i, j int) bool {
+		if desc {
+			return less(dagruns[j], dagruns[i])
+		}
+		return less(dagruns[i], dagruns[j])
+	})
+}
+
+func progress(row api.UIDagrunRow) float64 {
+	if row.TaskNum == 0 {
+		return 1
+	}
+	return float64(row.TaskCompletedNum) / float64(row.TaskNum)
+}
@@ -1,24 +1,42 @@
 package ui
 
 import (
+	"errors"
 	"log/slog"
 	"net/http"
+	"time"
 
 	"github.com/ppacer/core/scheduler"
 )
 
+const (
+	dagsErr = "dagsErr"
+
+	auditActionPauseDag   = "pause_dag"
+	auditActionUnpauseDag = "unpause_dag"
+)
+
+// Filters of DAGs list on "DAGs" page.
+const (
+	dagsFilterAll    = "all"
+	dagsFilterPaused = "paused"
+	dagsFilterActive = "active"
+)
+
 type pageDags struct {
 	Page    string
 	Version string
 
 	templates *templates
 	schedApi  scheduler.API
+	audit     *auditLog
 	logger    *slog.Logger
+	config    Config
 }
 
 func newPageDags(
-	schedApi scheduler.API, tmpl *templates, logger *slog.Logger,
-	config Config,
+	schedApi scheduler.API, audit *auditLog, tmpl *templates,
+	logger *slog.Logger, config Config,
 ) *pageDags {
 	if logger == nil {
 		logger = defaultLogger()
@@ -28,17 +46,207 @@ func newPageDags(
 		Version:   Version,
 		templates: tmpl,
 		schedApi:  schedApi,
+		audit:     audit,
 		logger:    logger,
+		config:    config,
+	}
+}
+
+// DagsView represents data rendered on "DAGs" page.
+type DagsView struct {
+	Page      string
+	Filter    string
+	Dags      []DagRow
+	AllNum    int
+	PausedNum int
+	Supported bool
+	Errors    map[string]string
+	Version   string
+}
+
+// DagRow represents a single DAG on "DAGs" page. Err is set, when an action
+// performed on the DAG failed.
+type DagRow struct {
+	DagState
+	Err string
+}
+
+// PausedSinceDisplay returns formatted timestamp since when the DAG is
+// paused.
+func (d DagRow) PausedSinceDisplay() string {
+	if d.PausedSince.IsZero() {
+		return ""
 	}
+	return d.PausedSince.Format(auditDisplayFormat)
 }
 
-// Main handler for "DAGs" page.
-func (pd *pageDags) MainHandler(w http.ResponseWriter, _ *http.Request) {
+// PausedFor returns for how long the DAG is paused, rounded to minutes.
+func (d DagRow) PausedFor() string {
+	if d.PausedSince.IsZero() {
+		return ""
+	}
+	return time.Since(d.PausedSince).Round(time.Minute).String()
+}
+
+// Main handler for "DAGs" page. DAGs might be filtered by their state using
+// filter query parameter.
+func (pd *pageDags) MainHandler(w http.ResponseWriter, r *http.Request) {
+	view := DagsView{
+		Page:    pd.Page,
+		Filter:  parseDagsFilter(r.URL.Query().Get("filter")),
+		Errors:  map[string]string{},
+		Version: pd.Version,
+	}
+	pauser, supported := pd.schedApi.(DagPauser)
+	view.Supported = supported
+	if !supported {
+		view.Errors[dagsErr] = "Connected Scheduler does not support listing and pausing DAGs"
+	} else {
+		states, err := pauser.UIDagStates()
+		if err != nil {
+			pd.logger.Error("Cannot read DAG states", "err", err.Error())
+			view.Errors[dagsErr] = "Cannot read DAGs: " + err.Error()
+		}
+		view.AllNum = len(states)
+		view.PausedNum = len(pausedDags(states))
+		view.Dags = filterDagStates(states, view.Filter)
+	}
+
 	w.Header().Set("Content-Type", "text/html; charset=utf-8")
-	renderErr := pd.templates.Render(w, "page_dags", pd)
+	renderErr := pd.templates.Render(w, "page_dags", view)
 	if renderErr != nil {
 		pd.logger.Error("Cannot render <page_dags>", "err",
 			renderErr.Error())
-		// TODO handle errors on UI
 	}
 }
+
+// HTTP handler which pauses DAG given by dagId form value. The reason is
+// mandatory and it's recorded in the audit log.
+func (pd *pageDags) PauseHandler(w http.ResponseWriter, r *http.Request) {
+	dagId := r.FormValue("dagId")
+	if dagId == "" {
+		pd.logger.Error("Empty dagId for pausing DAG")
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+	pauser, ok := pd.schedApi.(DagPauser)
+	if !ok {
+		pd.renderDagRow(w, dagId, errors.New(
+			"connected Scheduler does not support pausing DAGs"))
+		return
+	}
+	reason, err := parseReason(r.FormValue("reason"))
+	if err != nil {
+		pd.renderDagRow(w, dagId, err)
+		return
+	}
+	user := requestUser(r, pd.config.UserHeader)
+	pd.logger.Info("Pausing DAG", "dagId", dagId, "user", user)
+	pauseErr := pauser.PauseDag(dagId, user, reason)
+	event := AuditEvent{
+		User:   user,
+		Action: auditActionPauseDag,
+		DagId:  dagId,
+		Reason: reason,
+	}
+	if pauseErr != nil {
+		event.Err = pauseErr.Error()
+		pd.logger.Error("Error while pausing DAG", "dagId", dagId, "err",
+			pauseErr.Error())
+		pauseErr = errors.New("cannot pause DAG")
+	}
+	pd.audit.add(event)
+	pd.renderDagRow(w, dagId, pauseErr)
+}
+
+// HTTP handler which resumes scheduling of DAG given by dagId form value.
+func (pd *pageDags) UnpauseHandler(w http.ResponseWriter, r *http.Request) {
+	dagId := r.FormValue("dagId")
+	if dagId == "" {
+		pd.logger.Error("Empty dagId for unpausing DAG")
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+	pauser, ok := pd.schedApi.(DagPauser)
+	if !ok {
+		pd.renderDagRow(w, dagId, errors.New(
+			"connected Scheduler does not support pausing DAGs"))
+		return
+	}
+	user := requestUser(r, pd.config.UserHeader)
+	pd.logger.Info("Unpausing DAG", "dagId", dagId, "user", user)
+	unpauseErr := pauser.UnpauseDag(dagId, user)
+	event := AuditEvent{
+		User:   user,
+		Action: auditActionUnpauseDag,
+		DagId:  dagId,
+	}
+	if unpauseErr != nil {
+		event.Err = unpauseErr.Error()
+		pd.logger.Error("Error while unpausing DAG", "dagId", dagId, "err",
+			unpauseErr.Error())
+		unpauseErr = errors.New("cannot unpause DAG")
+	}
+	pd.audit.add(event)
+	pd.renderDagRow(w, dagId, unpauseErr)
+}
+
+// Renders a single row of DAGs table with the current state of given DAG and
+// given error.
+func (pd *pageDags) renderDagRow(
+	w http.ResponseWriter, dagId string, actionErr error,
+) {
+	row := DagRow{DagState: DagState{DagId: dagId}}
+	if pauser, ok := pd.schedApi.(DagPauser); ok {
+		states, err := pauser.UIDagStates()
+		if err != nil {
+			pd.logger.Error("Cannot read DAG states", "err", err.Error())
+			actionErr = errors.Join(actionErr, errors.New("cannot read DAGs"))
+		}
+		for _, state := range states {
+			if state.DagId == dagId {
+				row.DagState = state
+				break
+			}
+		}
+	}
+	if actionErr != nil {
+		row.Err = actionErr.Error()
+	}
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	if err := pd.templates.Render(w, "dag_row", row); err != nil {
+		pd.logger.Error("Cannot render <dag_row>", "err", err.Error())
+	}
+}
+
+func parseDagsFilter(filter string) string {
+	switch filter {
+	case dagsFilterPaused, dagsFilterActive:
+		return filter
+	}
+	return dagsFilterAll
+}
+
+// Returns DAGs matching given filter.
+func filterDagStates(states []DagState, filter string) []DagRow {
+	rows := make([]DagRow, 0, len(states))
+	for _, state := range states {
+		if filter == dagsFilterPaused && !state.Paused ||
+			filter == dagsFilterActive && state.Paused {
+			continue
+		}
+		rows = append(rows, DagRow{DagState: state})
+	}
+	return rows
+}
+
+// Returns paused DAGs from given DAG states.
+func pausedDags(states []DagState) []DagState {
+	var paused []DagState
+	for _, state := range states {
+		if state.Paused {
+			paused = append(paused, state)
+		}
+	}
+	return paused
+}
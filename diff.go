@@ -0,0 +1,108 @@
+package ui
+
+const (
+	diffSame    = "same"
+	diffAdded   = "added"
+	diffRemoved = "removed"
+
+	// Maximum size of LCS table, for larger inputs lines are compared
+	// position by position.
+	maxDiffCells = 4_000_000
+)
+
+// DiffLine represents a single line of a line based diff. Kind is one of
+// "same", "added" or "removed". Old and New are 1-based line numbers in the
+// old and the new text, or 0 when the line does not exist there.
+type DiffLine struct {
+	Kind string
+	Text string
+	Old  int
+	New  int
+}
+
+// IsSame checks if the line is the same in both texts.
+func (dl DiffLine) IsSame() bool { return dl.Kind == diffSame }
+
+// IsAdded checks if the line exists only in the new text.
+func (dl DiffLine) IsAdded() bool { return dl.Kind == diffAdded }
+
+// IsRemoved checks if the line exists only in the old text.
+func (dl DiffLine) IsRemoved() bool { return dl.Kind == diffRemoved }
+
+// diffLines computes line based diff between old and new lines using the
+// longest common subsequence. For very long inputs, lines are compared
+// position by position instead.
+func diffLines(oldLines, newLines []string) []DiffLine {
+	n, m := len(oldLines), len(newLines)
+	if n*m > maxDiffCells {
+		return diffByPosition(oldLines, newLines)
+	}
+
+	// lcs[i][j] is the length of LCS of oldLines[i:] and newLines[j:].
+	lcs := make([][]int, n+1)
+	for i := range lcs {
+		lcs[i] = make([]int, m+1)
+	}
+	for i := n - 1; i >= 0; i-- {
+		for j := m - 1; j >= 0; j-- {
+			if oldLines[i] == newLines[j] {
+				lcs[i][j] = lcs[i+1][j+1] + 1
+			} else {
+				lcs[i][j] = max(lcs[i+1][j], lcs[i][j+1])
+			}
+		}
+	}
+
+	diff := make([]DiffLine, 0, max(n, m))
+	i, j := 0, 0
+	for i < n && j < m {
+		switch {
+		case oldLines[i] == newLines[j]:
+			diff = append(diff, DiffLine{diffSame, oldLines[i], i + 1, j + 1})
+			i++
+			j++
+		case lcs[i+1][j] >= lcs[i][j+1]:
+			diff = append(diff, DiffLine{diffRemoved, oldLines[i], i + 1, 0})
+			i++
+		default:
+			diff = append(diff, DiffLine{diffAdded, newLines[j], 0, j + 1})
+			j++
+		}
+	}
+	for ; i < n; i++ {
+		diff = append(diff, DiffLine{diffRemoved, oldLines[i], i + 1, 0})
+	}
+	for ; j < m; j++ {
+		diff = append(diff, DiffLine{diffAdded, newLines[j], 0, j + 1})
+	}
+	return diff
+}
+
+func diffByPosition(oldLines, newLines []string) []DiffLine {
+	diff := make([]DiffLine, 0, max(len(oldLines), len(newLines)))
+	for i := 0; i < max(len(oldLines), len(newLines)); i++ {
+		switch {
+		case i < len(oldLines) && i < len(newLines) && oldLines[i] == newLines[i]:
+			diff = append(diff, DiffLine{diffSame, oldLines[i], i + 1, i + 1})
+		default:
+			if i < len(oldLines) {
+				diff = append(diff, DiffLine{diffRemoved, oldLines[i], i + 1, 0})
+			}
+			if i < len(newLines) {
+				diff = append(diff, DiffLine{diffAdded, newLines[i], 0, i + 1})
+			}
+		}
+	}
+	return diff
+}
+
+// diffChanges counts added and removed lines in the diff.
+func diffChanges(diff []DiffLine) int {
+	changes := 0
+	for _, line := range diff {
+		if !line.IsSame() {
+			changes++
+		}
+	}
+	return changes
+}
@@ -0,0 +1,157 @@
+package ui
+
+import (
+	"time"
+
+	"github.com/ppacer/core/api"
+	"github.com/ppacer/core/dag"
+	"github.com/ppacer/core/dag/schedule"
+)
+
+// This file defines optional extensions of scheduler.API. Scheduler clients
+// might implement some of those interfaces to enable additional UI features.
+// Pages check for them using type assertions and gracefully disable given
+// feature, when Scheduler client does not support it. SchedulerMock
+// implements all of them.
+
+// DagRunBackfiller is implemented by Scheduler clients which can schedule DAG
+// runs for arbitrary execution timestamps. It's used by the "Backfill" page.
+type DagRunBackfiller interface {
+	// DagSchedule returns schedule of given DAG. If DAG has no schedule, nil
+	// is returned.
+	DagSchedule(dagId string) (schedule.Schedule, error)
+
+	// DagRunExists checks if DAG run for given DAG and execution timestamp
+	// already exists.
+	DagRunExists(dagId string, execTs time.Time) (bool, error)
+
+	// TriggerDagRunAt schedules new DAG run, similarly to
+	// scheduler.API.TriggerDagRun, but for given execution timestamp.
+	TriggerDagRunAt(in api.DagRunTriggerInput, execTs time.Time) error
+}
+
+// DagTaskGraph is implemented by Scheduler clients which can provide DAG
+// structure (edges between tasks). It's used to draw edges in DAG run graph
+// view.
+type DagTaskGraph interface {
+	// DagTaskParents returns mapping from task ID to IDs of its parents for
+	// given DAG.
+	DagTaskParents(dagId string) (map[string][]string, error)
+}
+
+// DagRunQuery describes filters for listing DAG runs. Zero value of a field
+// means no filtering by that field.
+type DagRunQuery struct {
+	DagId       string
+	Statuses    []string
+	Since       time.Time
+	Until       time.Time
+	BeforeRunId int64
+	Limit       int
+}
+
+// DagRunHistory is implemented by Scheduler clients which can list historical
+// DAG runs. When it's not implemented, UI filters the latest DAG runs
+// returned by scheduler.API.UIDagrunLatest.
+type DagRunHistory interface {
+	// UIDagrunQuery returns DAG runs matching given query ordered from the
+	// newest one.
+	UIDagrunQuery(q DagRunQuery) (api.UIDagrunList, error)
+}
+
+// TaskLogsReader is implemented by Scheduler clients which can read arbitrary
+// window of DAG run task log records. It's used for loading more log records
+// than scheduler.API.UIDagrunTaskDetails returns.
+type TaskLogsReader interface {
+	// UIDagrunTaskLogs returns at most limit log records of given DAG run
+	// task attempt, starting from offset. Records are ordered from the
+	// oldest, so offset 0 means the first log record. LogRecordsCount is the
+	// total number of log records for the task attempt.
+	UIDagrunTaskLogs(
+		runId int, taskId string, retry int, offset, limit int,
+	) (api.UITaskLogs, error)
+}
+
+// DagRunCanceller is implemented by Scheduler clients which can cancel DAG
+// runs and single tasks. It's used by DAG run actions on DAG run details page.
+// Cancelled DAG runs and tasks are marked as failed.
+type DagRunCanceller interface {
+	// CancelDagRun stops scheduling new tasks of given DAG run and cancels
+	// its running tasks.
+	CancelDagRun(runId int) error
+
+	// CancelTask cancels given DAG run task attempt.
+	CancelTask(runId int, taskId string, retry int) error
+}
+
+// DagRunTaskClearer is implemented by Scheduler clients which can reset
+// finished tasks of a DAG run, so they are executed again. It's used by task
+// actions on DAG run details page.
+type DagRunTaskClearer interface {
+	// ClearTasks resets given tasks of DAG run. New attempts of those tasks
+	// are scheduled once their parents succeed.
+	ClearTasks(runId int, taskIds []string) error
+}
+
+// DagRunStatusSetter is implemented by Scheduler clients which can set status
+// of a DAG run manually. It's used for marking DAG runs as succeeded or failed
+// on DAG run details page. Tasks are marked using
+// scheduler.API.UpsertTaskStatus, which doesn't require any extension.
+type DagRunStatusSetter interface {
+	// SetDagRunStatus sets status of given DAG run.
+	SetDagRunStatus(runId int, status dag.RunStatus) error
+}
+
+// DagState describes a DAG and whether scheduling of its new DAG runs is
+// paused. Pause details are empty, when DAG is not paused.
+type DagState struct {
+	DagId       string
+	Paused      bool
+	PausedBy    string
+	PausedSince time.Time
+	PauseReason string
+}
+
+// DagPauser is implemented by Scheduler clients which can pause scheduling of
+// new DAG runs for a DAG. It's used by the "DAGs" page and the banner of
+// paused DAGs on the "Runs" page.
+type DagPauser interface {
+	// UIDagStates returns states of all DAGs ordered by DAG ID.
+	UIDagStates() ([]DagState, error)
+
+	// PauseDag stops scheduling new DAG runs of given DAG. DAG runs which
+	// already started are not affected.
+	PauseDag(dagId, user, reason string) error
+
+	// UnpauseDag resumes scheduling new DAG runs of given DAG.
+	UnpauseDag(dagId, user string) error
+}
+
+// QueuedDagRun represents DAG run waiting in the Scheduler DAG runs queue.
+// EnqueuedAt is zero, when the Scheduler doesn't track enqueue time.
+type QueuedDagRun struct {
+	DagId      string
+	ExecTs     time.Time
+	EnqueuedAt time.Time
+}
+
+// QueuedTask represents DAG run task waiting in the Scheduler task queue.
+// EnqueuedAt is zero, when the Scheduler doesn't track enqueue time.
+type QueuedTask struct {
+	DagId      string
+	ExecTs     time.Time
+	TaskId     string
+	Retry      int
+	EnqueuedAt time.Time
+}
+
+// QueueInspector is implemented by Scheduler clients which can list items of
+// the Scheduler queues. Lengths of those queues are reported in
+// api.UIDagrunStats. It's used by the "Queues" page.
+type QueueInspector interface {
+	// UIDagrunQueue returns DAG runs in the DAG runs queue, in queue order.
+	UIDagrunQueue() ([]QueuedDagRun, error)
+
+	// UITaskQueue returns tasks in the task scheduler queue, in queue order.
+	UITaskQueue() ([]QueuedTask, error)
+}
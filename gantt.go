@@ -0,0 +1,174 @@
+package ui
+
+import (
+	"fmt"
+	"time"
+)
+
+const (
+	ganttLabelWidth  = 200
+	ganttChartWidth  = 800
+	ganttRowHeight   = 26
+	ganttBarHeight   = 18
+	ganttAxisHeight  = 30
+	ganttPadding     = 10
+	ganttMinBarWidth = 2
+	ganttMaxTicks    = 6
+	ganttTimeFormat  = "2006-01-02 15:04:05.000000"
+)
+
+// Candidates for distance between Gantt chart axis ticks.
+var ganttTickSteps = []time.Duration{
+	time.Millisecond, 2 * time.Millisecond, 5 * time.Millisecond,
+	10 * time.Millisecond, 20 * time.Millisecond, 50 * time.Millisecond,
+	100 * time.Millisecond, 200 * time.Millisecond, 500 * time.Millisecond,
+	time.Second, 2 * time.Second, 5 * time.Second, 10 * time.Second,
+	15 * time.Second, 30 * time.Second, time.Minute, 2 * time.Minute,
+	5 * time.Minute, 10 * time.Minute, 15 * time.Minute, 30 * time.Minute,
+	time.Hour, 2 * time.Hour, 6 * time.Hour, 12 * time.Hour, 24 * time.Hour,
+	7 * 24 * time.Hour,
+}
+
+// DagrunGantt represents Gantt chart of DAG run task attempts. Each attempt
+// has its own row, attempts of the same task are placed in consecutive rows.
+// Time axis is relative to the start of the first attempt.
+type DagrunGantt struct {
+	Width      int
+	Height     int
+	ChartX     int
+	AxisY      int
+	Rows       []GanttRow
+	Bars       []GanttBar
+	Ticks      []GanttTick
+	Total      string
+	NotStarted int
+}
+
+// GanttRow represents a label of single Gantt chart row.
+type GanttRow struct {
+	Label string
+	Retry int
+	Y     int
+	LineY int
+}
+
+// GanttBar represents single task attempt on Gantt chart.
+type GanttBar struct {
+	TaskId   string
+	Retry    int
+	Status   string
+	Color    string
+	X        int
+	Y        int
+	W        int
+	H        int
+	Start    string
+	End      string
+	Offset   string
+	Duration string
+}
+
+// GanttTick represents a tick on Gantt chart time axis.
+type GanttTick struct {
+	X     int
+	Label string
+}
+
+// buildDagrunGantt prepares Gantt chart for given DAG run tasks. Task attempts
+// which were not started or which timestamps cannot be parsed are skipped and
+// counted in NotStarted. Running attempts without duration last until now.
+func buildDagrunGantt(tasks []DagrunTask, now time.Time) DagrunGantt {
+	type attempt struct {
+		task  DagrunTask
+		start time.Time
+		end   time.Time
+	}
+	gantt := DagrunGantt{
+		ChartX: ganttLabelWidth,
+		AxisY:  ganttPadding + ganttAxisHeight,
+	}
+	attempts := make([]attempt, 0, len(tasks))
+	var runStart, runEnd time.Time
+
+	for _, task := range tasks {
+		if task.TaskNoStarted {
+			gantt.NotStarted++
+			continue
+		}
+		start, err := parseTimestamp(task.InsertTs)
+		if err != nil {
+			gantt.NotStarted++
+			continue
+		}
+		end := start
+		if dur, durErr := time.ParseDuration(task.Duration); durErr == nil {
+			end = start.Add(dur)
+		} else if task.Status == "RUNNING" && now.After(start) {
+			end = now
+		}
+		if runStart.IsZero() || start.Before(runStart) {
+			runStart = start
+		}
+		if end.After(runEnd) {
+			runEnd = end
+		}
+		attempts = append(attempts, attempt{task: task, start: start, end: end})
+	}
+
+	span := runEnd.Sub(runStart)
+	if span <= 0 {
+		span = time.Millisecond
+	}
+	scale := float64(ganttChartWidth) / float64(span)
+	toX := func(t time.Time) int {
+		return ganttLabelWidth + int(float64(t.Sub(runStart))*scale)
+	}
+
+	for i, a := range attempts {
+		rowY := gantt.AxisY + i*ganttRowHeight
+		label := a.task.TaskId
+		if i > 0 && attempts[i-1].task.TaskId == a.task.TaskId {
+			label = fmt.Sprintf("↻ retry %d", a.task.Retry)
+		}
+		gantt.Rows = append(gantt.Rows, GanttRow{
+			Label: truncateLabel(label, graphMaxLabelLen+6),
+			Retry: a.task.Retry,
+			Y:     rowY + ganttRowHeight/2 + 5,
+			LineY: rowY + ganttRowHeight,
+		})
+		x := toX(a.start)
+		gantt.Bars = append(gantt.Bars, GanttBar{
+			TaskId:   a.task.TaskId,
+			Retry:    a.task.Retry,
+			Status:   a.task.Status,
+			Color:    statusColor(a.task.Status),
+			X:        x,
+			Y:        rowY + (ganttRowHeight-ganttBarHeight)/2,
+			W:        max(toX(a.end)-x, ganttMinBarWidth),
+			H:        ganttBarHeight,
+			Start:    a.start.Format(ganttTimeFormat),
+			End:      a.end.Format(ganttTimeFormat),
+			Offset:   a.start.Sub(runStart).String(),
+			Duration: a.end.Sub(a.start).String(),
+		})
+	}
+
+	step := ganttTickSteps[len(ganttTickSteps)-1]
+	for _, candidate := range ganttTickSteps {
+		if span/candidate <= ganttMaxTicks {
+			step = candidate
+			break
+		}
+	}
+	for offset := time.Duration(0); offset <= span; offset += step {
+		gantt.Ticks = append(gantt.Ticks, GanttTick{
+			X:     toX(runStart.Add(offset)),
+			Label: "+" + offset.String(),
+		})
+	}
+
+	gantt.Total = span.String()
+	gantt.Width = ganttLabelWidth + ganttChartWidth + 4*ganttPadding
+	gantt.Height = gantt.AxisY + len(attempts)*ganttRowHeight + ganttPadding
+	return gantt
+}
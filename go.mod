@@ -2,7 +2,10 @@ module github.com/ppacer/ui
 
 go 1.22.0
 
-require github.com/ppacer/core v0.0.12-0.20241015203550-d37242b22d55
+require (
+	github.com/ppacer/core v0.0.12-0.20241015203550-d37242b22d55
+	modernc.org/sqlite v1.25.0
+)
 
 require (
 	github.com/dustin/go-humanize v1.0.1 // indirect
@@ -21,7 +24,6 @@ require (
 	modernc.org/mathutil v1.5.0 // indirect
 	modernc.org/memory v1.6.0 // indirect
 	modernc.org/opt v0.1.3 // indirect
-	modernc.org/sqlite v1.25.0 // indirect
 	modernc.org/strutil v1.1.3 // indirect
 	modernc.org/token v1.0.1 // indirect
 )
@@ -0,0 +1,195 @@
+package ui
+
+import (
+	"fmt"
+	"sort"
+)
+
+const (
+	maxGraphTasks     = 150
+	graphNodeWidth    = 160
+	graphNodeHeight   = 40
+	graphColumnGap    = 60
+	graphRowGap       = 20
+	graphPadding      = 20
+	graphMaxLabelLen  = 18
+	graphBadgeRadius  = 10
+	graphDefaultColor = "#6b7280"
+)
+
+// Colors of DAG run graph nodes and chart bars for given task status.
+var graphStatusColors = map[string]string{
+	"SUCCESS":              "#22c55e",
+	"FAILED":               "#ef4444",
+	"FAILED_PENDING_RETRY": "#f97316",
+	"UPSTREAM_FAILED":      "#a855f7",
+	"RUNNING":              "#eab308",
+	"SCHEDULED":            "#3b82f6",
+	"PENDING":              "#3b82f6",
+	"RESTARTING":           "#3b82f6",
+}
+
+// DagrunGraph represents DAG run tasks laid out as SVG graph. Nodes are placed
+// in columns by task depth and in rows by task width. When DAG run has more
+// than maxGraphTasks tasks, TooLarge is set and no nodes are computed.
+type DagrunGraph struct {
+	Width    int
+	Height   int
+	Nodes    []GraphNode
+	Edges    []GraphEdge
+	TooLarge bool
+	TasksNum int
+}
+
+// GraphNode represents single task on DAG run graph. Node represents the
+// latest attempt of the task. Retries is the number of earlier attempts.
+type GraphNode struct {
+	TaskId  string
+	Label   string
+	Retry   int
+	Retries int
+	Status  string
+	Color   string
+	X       int
+	Y       int
+	W       int
+	H       int
+	BadgeX  int
+	BadgeY  int
+	TextX   int
+	TextY   int
+}
+
+// GraphEdge represents an edge between parent and child task as SVG path.
+type GraphEdge struct {
+	Path string
+}
+
+// buildDagrunGraph lays out given DAG run tasks as a graph. Edges are based on
+// parents mapping (task ID -> parent task IDs), which might be nil, when DAG
+// structure is not available.
+func buildDagrunGraph(
+	tasks []DagrunTask, parents map[string][]string, maxTasks int,
+) DagrunGraph {
+	latest := make(map[string]DagrunTask)
+	attempts := make(map[string]int)
+	order := make([]string, 0)
+	for _, task := range tasks {
+		prev, exists := latest[task.TaskId]
+		if !exists {
+			order = append(order, task.TaskId)
+		}
+		if !exists || task.Retry >= prev.Retry {
+			latest[task.TaskId] = task
+		}
+		attempts[task.TaskId]++
+	}
+
+	graph := DagrunGraph{TasksNum: len(order)}
+	if len(order) > maxTasks {
+		graph.TooLarge = true
+		return graph
+	}
+	if len(order) == 0 {
+		return graph
+	}
+
+	minDepth := latest[order[0]].Pos.Depth
+	for _, taskId := range order {
+		minDepth = min(minDepth, latest[taskId].Pos.Depth)
+	}
+
+	// Rows are assigned by task width within each depth, to avoid gaps when
+	// widths are not consecutive.
+	columns := make(map[int][]string)
+	for _, taskId := range order {
+		depth := latest[taskId].Pos.Depth - minDepth
+		columns[depth] = append(columns[depth], taskId)
+	}
+
+	nodeIdx := make(map[string]int, len(order))
+	maxCol, maxRow := 0, 0
+	for col, taskIds := range columns {
+		sort.SliceStable(taskIds, func(i, j int) bool {
+			return latest[taskIds[i]].Pos.Width < latest[taskIds[j]].Pos.Width
+		})
+		for row, taskId := range taskIds {
+			task := latest[taskId]
+			x := graphPadding + col*(graphNodeWidth+graphColumnGap)
+			y := graphPadding + row*(graphNodeHeight+graphRowGap)
+			node := GraphNode{
+				TaskId:  taskId,
+				Label:   truncateLabel(taskId, graphMaxLabelLen),
+				Retry:   task.Retry,
+				Retries: attempts[taskId] - 1,
+				Status:  task.Status,
+				Color:   statusColor(task.Status),
+				X:       x,
+				Y:       y,
+				W:       graphNodeWidth,
+				H:       graphNodeHeight,
+				BadgeX:  x + graphNodeWidth,
+				BadgeY:  y + 4,
+				TextX:   x + graphNodeWidth/2,
+				TextY:   y + graphNodeHeight/2 + 5,
+			}
+			graph.Nodes = append(graph.Nodes, node)
+			maxCol = max(maxCol, col)
+			maxRow = max(maxRow, row)
+		}
+	}
+	sort.Slice(graph.Nodes, func(i, j int) bool {
+		if graph.Nodes[i].X != graph.Nodes[j].X {
+			return graph.Nodes[i].X < graph.Nodes[j].X
+		}
+		return graph.Nodes[i].Y < graph.Nodes[j].Y
+	})
+	for i, node := range graph.Nodes {
+		nodeIdx[node.TaskId] = i
+	}
+
+	for _, taskId := range order {
+		child := graph.Nodes[nodeIdx[taskId]]
+		for _, parentId := range parents[taskId] {
+			pIdx, exists := nodeIdx[parentId]
+			if !exists {
+				continue
+			}
+			graph.Edges = append(graph.Edges, graphEdge(graph.Nodes[pIdx], child))
+		}
+	}
+
+	graph.Width = 2*graphPadding + (maxCol+1)*graphNodeWidth +
+		maxCol*graphColumnGap + graphBadgeRadius
+	graph.Height = 2*graphPadding + (maxRow+1)*graphNodeHeight +
+		maxRow*graphRowGap
+	return graph
+}
+
+// Prepares SVG path (cubic Bezier curve) from the right side of parent node to
+// the left side of child node.
+func graphEdge(parent, child GraphNode) GraphEdge {
+	x1, y1 := parent.X+parent.W, parent.Y+parent.H/2
+	x2, y2 := child.X, child.Y+child.H/2
+	midX := (x1 + x2) / 2
+	return GraphEdge{
+		Path: fmt.Sprintf("M %d %d C %d %d, %d %d, %d %d",
+			x1, y1, midX, y1, midX, y2, x2, y2),
+	}
+}
+
+// Returns color (hex) of given task status used in graphs and charts.
+func statusColor(status string) string {
+	if color, ok := graphStatusColors[status]; ok {
+		return color
+	}
+	return graphDefaultColor
+}
+
+func truncateLabel(s string, maxLen int) string {
+	runes := []rune(s)
+	if len(runes) <= maxLen {
+		return s
+	}
+	return string(runes[:maxLen-1]) + "…"
+}
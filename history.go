@@ -0,0 +1,248 @@
+package ui
+
+import (
+	"fmt"
+	"slices"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/ppacer/core/api"
+	"github.com/ppacer/core/dag"
+	"github.com/ppacer/core/scheduler"
+)
+
+const (
+	// Number of the latest DAG runs scanned on the UI side, when Scheduler
+	// client does not implement DagRunHistory.
+	dagRunScanLimit = 1000
+
+	// For how long the latest DAG runs scanned on the UI side are reused by
+	// subsequent queries.
+	dagRunScanCacheTTL = 5 * time.Second
+
+	// Relative duration change which is considered as regression or
+	// improvement.
+	durationChangeThreshold = 0.1
+)
+
+// dagRunSource lists DAG runs matching queries of history, statistics
+// breakdown, alert rules and DAG run duration comparison. When Scheduler
+// client does not implement DagRunHistory, the latest dagRunScanLimit DAG runs
+// are filtered on the UI side. Those are cached for dagRunScanCacheTTL, so
+// rendering pages and evaluating alert rules at the same time doesn't scan
+// them repeatedly. Scanned DAG runs are saved in the history store, which
+// provides older DAG runs, which are no longer scanned.
+type dagRunSource struct {
+	schedApi scheduler.API
+	store    *historyStore
+
+	mu     sync.Mutex
+	scan   api.UIDagrunList
+	scanTs time.Time
+}
+
+func newDagRunSource(
+	schedApi scheduler.API, store *historyStore,
+) *dagRunSource {
+	return &dagRunSource{schedApi: schedApi, store: store}
+}
+
+// query lists DAG runs matching given query, ordered from the newest one.
+func (ds *dagRunSource) query(q DagRunQuery) (api.UIDagrunList, error) {
+	if history, ok := ds.schedApi.(DagRunHistory); ok {
+		return history.UIDagrunQuery(q)
+	}
+	latest, err := ds.latest()
+	if err != nil {
+		return nil, err
+	}
+	result := make(api.UIDagrunList, 0)
+	scanned := make(map[int64]struct{}, len(latest))
+	for _, row := range latest {
+		scanned[row.RunId] = struct{}{}
+		if q.matches(row) {
+			result = append(result, row)
+		}
+	}
+	stored, err := ds.store.queryRuns(q)
+	if err != nil {
+		return nil, fmt.Errorf("cannot read DAG runs from history store: %w",
+			err)
+	}
+	for _, row := range stored {
+		// Scheduler state of scanned DAG runs is more recent
+		if _, exists := scanned[row.RunId]; !exists {
+			result = append(result, row)
+		}
+	}
+	sort.SliceStable(result, func(i, j int) bool {
+		return result[i].RunId > result[j].RunId
+	})
+	if q.Limit > 0 && len(result) > q.Limit {
+		result = result[:q.Limit]
+	}
+	return result, nil
+}
+
+// Returns the latest dagRunScanLimit DAG runs, reusing the previous scan when
+// it's not older than dagRunScanCacheTTL. Returned list must not be modified.
+func (ds *dagRunSource) latest() (api.UIDagrunList, error) {
+	ds.mu.Lock()
+	defer ds.mu.Unlock()
+	if ds.scan != nil && time.Since(ds.scanTs) < dagRunScanCacheTTL {
+		return ds.scan, nil
+	}
+	latest, err := ds.schedApi.UIDagrunLatest(dagRunScanLimit)
+	if err != nil {
+		return nil, err
+	}
+	ds.scan, ds.scanTs = latest, time.Now()
+	ds.store.saveRuns(latest)
+	return latest, nil
+}
+
+// Checks if given DAG run row matches the query.
+func (q DagRunQuery) matches(row api.UIDagrunRow) bool {
+	if q.DagId != "" && row.DagId != q.DagId {
+		return false
+	}
+	if q.BeforeRunId > 0 && row.RunId >= q.BeforeRunId {
+		return false
+	}
+	if len(q.Statuses) > 0 && !slices.Contains(q.Statuses, row.Status) {
+		return false
+	}
+	if q.Since.IsZero() && q.Until.IsZero() {
+		return true
+	}
+	execTs, err := parseTimestamp(row.ExecTs)
+	if err != nil {
+		return false
+	}
+	if !q.Since.IsZero() && execTs.Before(q.Since) {
+		return false
+	}
+	if !q.Until.IsZero() && execTs.After(q.Until) {
+		return false
+	}
+	return true
+}
+
+// DurationComparison compares DAG run duration with median duration of
+// previous successful DAG runs of the same DAG.
+type DurationComparison struct {
+	Available   bool
+	Reason      string
+	RunsNum     int
+	ExpectedNum int
+	Median      string
+	Delta       string
+	Percent     string
+	Regression  bool
+	Improvement bool
+}
+
+// compareDuration compares given duration with median of durations of
+// previous DAG runs. When previous DAG runs are less then expected n, the
+// comparison is based on available DAG runs.
+func compareDuration(
+	duration string, previous api.UIDagrunList, n int,
+) DurationComparison {
+	cmp := DurationComparison{ExpectedNum: n}
+	current, err := time.ParseDuration(duration)
+	if err != nil {
+		cmp.Reason = "n/a (DAG run not finished)"
+		return cmp
+	}
+
+	durations := make([]time.Duration, 0, len(previous))
+	for _, row := range previous {
+		d, parseErr := time.ParseDuration(row.Duration)
+		if parseErr != nil {
+			continue
+		}
+		durations = append(durations, d)
+		if len(durations) == n {
+			break
+		}
+	}
+	if len(durations) == 0 {
+		cmp.Reason = "n/a (no previous runs)"
+		return cmp
+	}
+
+	median := medianDuration(durations)
+	delta := current - median
+	cmp.Available = true
+	cmp.RunsNum = len(durations)
+	cmp.Median = roundDuration(median).String()
+	cmp.Delta = formatDurationDelta(delta)
+	cmp.Percent, cmp.Regression, cmp.Improvement = relativeChange(delta, median)
+	return cmp
+}
+
+// Computes relative duration change to the base duration. Returns formatted
+// percent and flags whether the change is considered as regression or
+// improvement. For non-positive base, the change is not computed.
+func relativeChange(delta, base time.Duration) (string, bool, bool) {
+	if base <= 0 {
+		return "", false, false
+	}
+	change := float64(delta) / float64(base)
+	return fmt.Sprintf("%+.0f%%", change*100),
+		change > durationChangeThreshold,
+		change < -durationChangeThreshold
+}
+
+// Computes previous successful DAG runs of the same DAG and compares DAG run
+// duration to them.
+func dagrunDurationComparison(
+	runs *dagRunSource, drd api.UIDagrunDetails, n int,
+) (DurationComparison, error) {
+	if n <= 0 {
+		n = DefaultConfig.DurationCompareRuns
+	}
+	q := DagRunQuery{
+		DagId:       drd.DagId,
+		Statuses:    []string{dag.RunSuccess.String()},
+		BeforeRunId: drd.RunId,
+		Limit:       n,
+	}
+	previous, err := runs.query(q)
+	if err != nil {
+		return DurationComparison{ExpectedNum: n, Reason: "n/a"}, err
+	}
+	return compareDuration(drd.Duration, previous, n), nil
+}
+
+func medianDuration(durations []time.Duration) time.Duration {
+	sorted := make([]time.Duration, len(durations))
+	copy(sorted, durations)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i] < sorted[j] })
+	mid := len(sorted) / 2
+	if len(sorted)%2 == 1 {
+		return sorted[mid]
+	}
+	return (sorted[mid-1] + sorted[mid]) / 2
+}
+
+// Rounds duration to reasonable precision for displaying.
+func roundDuration(d time.Duration) time.Duration {
+	abs := d.Abs()
+	switch {
+	case abs >= time.Minute:
+		return d.Round(time.Second)
+	case abs >= 10*time.Millisecond:
+		return d.Round(time.Millisecond)
+	default:
+		return d.Round(time.Microsecond)
+	}
+}
+
+func formatDurationDelta(d time.Duration) string {
+	if d < 0 {
+		return "-" + roundDuration(-d).String()
+	}
+	return "+" + roundDuration(d).String()
+}
@@ -0,0 +1,149 @@
+package ui
+
+import (
+	"fmt"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/ppacer/core/api"
+)
+
+const (
+	historyErr = "historyErr"
+
+	// Number of DAG runs on a single page of "History" page.
+	historyPageSize = 25
+
+	// Maximum number of DAG runs on the first page, when the expected number
+	// of DAG runs is given.
+	maxExpectedRuns = 500
+)
+
+// Type pageHistory keeps dependencies required for "History" (/hist) page.
+type pageHistory struct {
+	templates *templates
+	runs      *dagRunSource
+	index     *runIndex
+	logger    *slog.Logger
+}
+
+// newPageHistory initialize new state for DAG runs history page.
+func newPageHistory(
+	runs *dagRunSource, index *runIndex, tmpl *templates,
+	logger *slog.Logger,
+) *pageHistory {
+	if logger == nil {
+		logger = defaultLogger()
+	}
+	return &pageHistory{
+		templates: tmpl, runs: runs, index: index, logger: logger,
+	}
+}
+
+// DagRunsHistory represents a single page of DAG runs matching the search
+// query. DAG runs are ordered from the newest one. Older is the value of
+// before parameter for the next page, or zero if there are no more DAG runs.
+// Expected is the number of DAG runs counted by the statistics card, which
+// linked to the page.
+type DagRunsHistory struct {
+	Page     string
+	Query    SearchQuery
+	DagRuns  api.UIDagrunList
+	Before   int64
+	Older    int64
+	Expected int
+	Errors   map[string]string
+	Version  string
+}
+
+// ExpectedTruncated checks if not all expected DAG runs fit on the page.
+func (h DagRunsHistory) ExpectedTruncated() bool {
+	return h.Expected > maxExpectedRuns
+}
+
+// MainHandler renders DAG runs matching the search query given by q query
+// parameter. DAG runs with IDs lower than before query parameter are listed.
+// When expected query parameter is set, the first page is extended to fit
+// that many DAG runs, so drill-down from statistics cards lists all counted
+// DAG runs.
+func (ph *pageHistory) MainHandler(w http.ResponseWriter, r *http.Request) {
+	q, err := parseSearchQuery(r.URL.Query().Get("q"), time.Now())
+	if err == nil && q.IsRunId {
+		http.Redirect(w, r, fmt.Sprintf("/dagruns/%d", q.RunId),
+			http.StatusSeeOther)
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	hist := DagRunsHistory{
+		Page:    "History",
+		Query:   q,
+		Errors:  map[string]string{},
+		Version: Version,
+	}
+	defer func() {
+		if err := ph.templates.Render(w, "page_history", hist); err != nil {
+			ph.logger.Error("Cannot render <page_history>", "err", err.Error())
+		}
+	}()
+
+	if err != nil {
+		hist.Errors[historyErr] = fmt.Sprintf("Invalid query: %s", err.Error())
+		return
+	}
+	hist.Before, _ = strconv.ParseInt(r.URL.Query().Get("before"), 10, 64)
+	pageSize := historyPageSize
+	if hist.Before == 0 {
+		hist.Expected = max(queryInt(r, "expected", 0), 0)
+		pageSize = max(pageSize, min(hist.Expected, maxExpectedRuns))
+	}
+
+	dagruns, err := historyDagRuns(ph.runs, q, hist.Before, pageSize+1)
+	if err != nil {
+		ph.logger.Error("Cannot read DAG runs history", "query", q.Raw, "err",
+			err.Error())
+		hist.Errors[historyErr] = fmt.Sprintf("Cannot read DAG runs: %s",
+			err.Error())
+		return
+	}
+	if len(dagruns) > pageSize {
+		dagruns = dagruns[:pageSize]
+		hist.Older = dagruns[pageSize-1].RunId
+	}
+	hist.DagRuns = dagruns
+	ph.index.addRuns(dagruns)
+}
+
+// historyDagRuns lists at most limit DAG runs matching the search query with
+// IDs lower than before (if positive). DAG ID prefixes, patterns and free
+// text are matched on the UI side.
+func historyDagRuns(
+	runs *dagRunSource, q SearchQuery, before int64, limit int,
+) (api.UIDagrunList, error) {
+	dq := DagRunQuery{
+		Statuses:    q.Statuses,
+		Since:       q.sinceTs,
+		BeforeRunId: before,
+		Limit:       limit,
+	}
+	if q.DagId != "" || q.Text != "" {
+		dq.Limit = dagRunScanLimit
+	}
+	candidates, err := runs.query(dq)
+	if err != nil {
+		return nil, err
+	}
+	dagruns := make(api.UIDagrunList, 0, limit)
+	for _, row := range candidates {
+		if !q.matches(row) {
+			continue
+		}
+		dagruns = append(dagruns, row)
+		if len(dagruns) == limit {
+			break
+		}
+	}
+	return dagruns, nil
+}
@@ -0,0 +1,125 @@
+package ui
+
+import (
+	"bytes"
+	"encoding/json"
+	"sort"
+	"strings"
+)
+
+const (
+	// Maximum length of log attribute value displayed without expanding it.
+	maxLogAttrValueLen = 60
+
+	// Maximum depth of nested log attributes, deeper objects are displayed as
+	// JSON values.
+	maxLogAttrDepth = 5
+)
+
+// LogAttr represents a single attribute of a task log record. Path is the key
+// prefixed by keys of parent attributes, separated by dots. Attributes which
+// are JSON objects have Nested attributes instead of a value.
+type LogAttr struct {
+	Key       string
+	Path      string
+	Value     string
+	Short     string
+	Truncated bool
+	Nested    []LogAttr
+}
+
+// IsObject checks if the attribute is a JSON object with nested attributes.
+func (la LogAttr) IsObject() bool {
+	return la.Nested != nil
+}
+
+// Attributes parses AttributesJson of the log record. For empty attributes or
+// attributes which are not a JSON object, nil is returned.
+func (tlr TaskLogRecord) Attributes() []LogAttr {
+	attrs, ok := parseLogAttributes(tlr.AttributesJson)
+	if !ok {
+		return nil
+	}
+	return attrs
+}
+
+// parseLogAttributes parses JSON object of log record attributes. Attributes
+// are sorted by keys. Second value is false, when the input is not a JSON
+// object.
+func parseLogAttributes(attributesJson string) ([]LogAttr, bool) {
+	var obj map[string]json.RawMessage
+	if err := json.Unmarshal([]byte(attributesJson), &obj); err != nil {
+		return nil, false
+	}
+	return toLogAttrs(obj, "", 1), true
+}
+
+func toLogAttrs(obj map[string]json.RawMessage, prefix string, depth int) []LogAttr {
+	keys := make([]string, 0, len(obj))
+	for key := range obj {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	attrs := make([]LogAttr, 0, len(keys))
+	for _, key := range keys {
+		attr := LogAttr{Key: key, Path: prefix + key}
+		raw := obj[key]
+		var nested map[string]json.RawMessage
+		if depth < maxLogAttrDepth && json.Unmarshal(raw, &nested) == nil &&
+			nested != nil {
+			attr.Nested = toLogAttrs(nested, attr.Path+".", depth+1)
+			attrs = append(attrs, attr)
+			continue
+		}
+		attr.Value = logAttrValue(raw)
+		attr.Short = attr.Value
+		if len([]rune(attr.Value)) > maxLogAttrValueLen {
+			attr.Short = string([]rune(attr.Value)[:maxLogAttrValueLen]) + "…"
+			attr.Truncated = true
+		}
+		attrs = append(attrs, attr)
+	}
+	return attrs
+}
+
+// Converts raw JSON value into displayed value. Strings are unquoted, other
+// values are displayed as compact JSON.
+func logAttrValue(raw json.RawMessage) string {
+	var s string
+	if json.Unmarshal(raw, &s) == nil {
+		return s
+	}
+	var buf bytes.Buffer
+	if json.Compact(&buf, raw) != nil {
+		return strings.TrimSpace(string(raw))
+	}
+	return buf.String()
+}
+
+// Checks if log record attributes contain attribute of given path and value.
+func hasLogAttr(attributesJson, path, value string) bool {
+	attrs, ok := parseLogAttributes(attributesJson)
+	if !ok {
+		return false
+	}
+	for _, attr := range flattenLogAttrs(attrs) {
+		if attr.Path == path && attr.Value == value {
+			return true
+		}
+	}
+	return false
+}
+
+// Flattens nested log attributes into the list of attributes with values.
+func flattenLogAttrs(attrs []LogAttr) []LogAttr {
+	flat := make([]LogAttr, 0, len(attrs))
+	for _, attr := range attrs {
+		if attr.IsObject() {
+			flat = append(flat, flattenLogAttrs(attr.Nested)...)
+			continue
+		}
+		flat = append(flat, attr)
+	}
+	return flat
+}
@@ -0,0 +1,298 @@
+package ui
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/ppacer/core/api"
+	"github.com/ppacer/core/dag"
+)
+
+const (
+	auditActionMarkRun  = "mark_run"
+	auditActionMarkTask = "mark_task"
+
+	// Maximum length of the reason of actions which require it.
+	maxReasonLen = 500
+)
+
+var errNotLatestAttempt = errors.New("only the latest task attempt can be marked")
+
+// TaskMarkForm represents form for marking DAG run task attempt as succeeded
+// or failed. NotLatest is set, when the task attempt was retried since, in
+// which case it cannot be marked.
+type TaskMarkForm struct {
+	RunId     int64
+	TaskId    string
+	Retry     int
+	Status    string
+	Reason    string
+	NotLatest bool
+	Err       string
+}
+
+// Valid checks if the form status is one of statuses tasks can be marked
+// with and the task attempt is the latest one.
+func (f TaskMarkForm) Valid() bool {
+	_, _, err := parseMarkStatus(f.Status)
+	return err == nil && !f.NotLatest
+}
+
+// Parses status which DAG run or task is marked with. Only SUCCESS and FAILED
+// statuses are allowed.
+func parseMarkStatus(status string) (dag.TaskStatus, dag.RunStatus, error) {
+	switch status {
+	case dag.TaskSuccess.String():
+		return dag.TaskSuccess, dag.RunSuccess, nil
+	case dag.TaskFailed.String():
+		return dag.TaskFailed, dag.RunFailed, nil
+	}
+	return 0, 0, fmt.Errorf("invalid status %q, expected SUCCESS or FAILED",
+		status)
+}
+
+// Validates the reason of an action, like marking DAG run or pausing a DAG.
+// The reason is mandatory.
+func parseReason(reason string) (string, error) {
+	reason = strings.TrimSpace(reason)
+	if reason == "" {
+		return "", errors.New("reason is required")
+	}
+	if len(reason) > maxReasonLen {
+		return "", fmt.Errorf("reason is too long, the limit is %d characters",
+			maxReasonLen)
+	}
+	return reason, nil
+}
+
+// HTTP handler which renders form for marking DAG run task attempt with
+// status given by status query parameter.
+func (pdrd *pageDagRunDetails) TaskMarkFormHandler(
+	w http.ResponseWriter, r *http.Request,
+) {
+	runId, runErr := getPathValueInt(r, "runId")
+	taskId, taskErr := getPathValueStr(r, "taskId")
+	if runErr != nil || taskErr != nil {
+		pdrd.logger.Error("Invalid path arguments for TaskMarkFormHandler",
+			"runErr", runErr, "taskErr", taskErr)
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+	form := TaskMarkForm{
+		RunId:  int64(runId),
+		TaskId: taskId,
+		Retry:  queryInt(r, "retry", 0),
+		Status: r.URL.Query().Get("status"),
+	}
+	drd, err := pdrd.schedApi.UIDagrunDetails(runId)
+	if err != nil {
+		pdrd.logger.Error("Cannot read DAG run details", "runId", runId, "err",
+			err.Error())
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+	exists, latest := markableAttempt(drd, taskId, form.Retry)
+	if !exists {
+		pdrd.logger.Warn("Task attempt does not exist in DAG run", "runId",
+			runId, "taskId", taskId, "retry", form.Retry)
+		w.WriteHeader(http.StatusNotFound)
+		return
+	}
+	if _, _, err := parseMarkStatus(form.Status); err != nil {
+		form.Err = err.Error()
+	}
+	if !latest {
+		form.NotLatest = true
+		form.Err = errNotLatestAttempt.Error()
+	}
+	pdrd.renderTaskMarkForm(w, form)
+}
+
+// HTTP handler which marks DAG run task attempt as succeeded or failed, using
+// scheduler.API.UpsertTaskStatus. The reason is recorded in the audit log and
+// as an annotation of the DAG run.
+func (pdrd *pageDagRunDetails) MarkTaskHandler(
+	w http.ResponseWriter, r *http.Request,
+) {
+	runId, runErr := strconv.Atoi(r.FormValue("runId"))
+	retry, retryErr := strconv.Atoi(r.FormValue("retry"))
+	form := TaskMarkForm{
+		RunId:  int64(runId),
+		TaskId: r.FormValue("taskId"),
+		Retry:  retry,
+		Status: r.FormValue("status"),
+		Reason: r.FormValue("reason"),
+	}
+	if runErr != nil || retryErr != nil || form.TaskId == "" {
+		pdrd.logger.Error("Invalid input for marking task", "runId",
+			r.FormValue("runId"), "taskId", form.TaskId, "retry",
+			r.FormValue("retry"))
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+	taskStatus, _, statusErr := parseMarkStatus(form.Status)
+	reason, reasonErr := parseReason(form.Reason)
+	if err := errors.Join(statusErr, reasonErr); err != nil {
+		form.Err = err.Error()
+		pdrd.renderTaskMarkForm(w, form)
+		return
+	}
+	drd, err := pdrd.schedApi.UIDagrunDetails(runId)
+	if err != nil {
+		pdrd.logger.Error("Cannot read DAG run details", "runId", runId, "err",
+			err.Error())
+		form.Err = "Cannot read DAG run details"
+		pdrd.renderTaskMarkForm(w, form)
+		return
+	}
+	exists, latest := markableAttempt(drd, form.TaskId, form.Retry)
+	if !exists {
+		pdrd.logger.Warn("Task attempt does not exist in DAG run", "runId",
+			runId, "taskId", form.TaskId, "retry", form.Retry)
+		w.WriteHeader(http.StatusNotFound)
+		return
+	}
+	if !latest {
+		form.NotLatest = true
+		form.Err = errNotLatestAttempt.Error()
+		pdrd.renderTaskMarkForm(w, form)
+		return
+	}
+
+	tte := api.TaskToExec{
+		DagId:  drd.DagId,
+		ExecTs: drd.ExecTsRaw,
+		TaskId: form.TaskId,
+		Retry:  form.Retry,
+	}
+	var taskErr error
+	if taskStatus == dag.TaskFailed {
+		taskErr = fmt.Errorf("marked as failed via UI: %s", reason)
+	}
+	pdrd.logger.Info("Marking task", "tte", tte, "status", form.Status)
+	upsertErr := pdrd.schedApi.UpsertTaskStatus(tte, taskStatus, taskErr)
+	user := requestUser(r, pdrd.config.UserHeader)
+	event := AuditEvent{
+		User:    user,
+		Action:  auditActionMarkTask,
+		RunId:   drd.RunId,
+		DagId:   drd.DagId,
+		TaskId:  form.TaskId,
+		Details: fmt.Sprintf("retry %d marked as %s", form.Retry, form.Status),
+		Reason:  reason,
+	}
+	if upsertErr != nil {
+		event.Err = upsertErr.Error()
+	}
+	pdrd.audit.add(event)
+	if upsertErr != nil {
+		pdrd.logger.Error("Error while marking task", "tte", tte, "err",
+			upsertErr.Error())
+		form.Err = "Cannot update task status"
+		pdrd.renderTaskMarkForm(w, form)
+		return
+	}
+	pdrd.annotations.add(Annotation{
+		User:   user,
+		RunId:  drd.RunId,
+		TaskId: form.TaskId,
+		Text:   fmt.Sprintf("Marked as %s: %s", form.Status, reason),
+	})
+	w.Header().Set("HX-Redirect", fmt.Sprintf("/dagruns/%d", runId))
+	w.WriteHeader(http.StatusOK)
+}
+
+// HTTP handler which marks DAG run as succeeded or failed, when Scheduler
+// client implements DagRunStatusSetter. The reason is recorded in the audit
+// log and as an annotation of the DAG run.
+func (pdrd *pageDagRunDetails) MarkDagRunHandler(
+	w http.ResponseWriter, r *http.Request,
+) {
+	runId, err := strconv.Atoi(r.FormValue("runId"))
+	if err != nil {
+		pdrd.logger.Error("Invalid runId for marking DAG run", "runId",
+			r.FormValue("runId"))
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+	setter, ok := pdrd.schedApi.(DagRunStatusSetter)
+	if !ok {
+		pdrd.renderActionsErr(w, runId,
+			"Connected Scheduler does not support setting DAG run status")
+		return
+	}
+	status := r.FormValue("status")
+	_, runStatus, statusErr := parseMarkStatus(status)
+	reason, reasonErr := parseReason(r.FormValue("reason"))
+	if err := errors.Join(statusErr, reasonErr); err != nil {
+		pdrd.renderActionsErr(w, runId, fmt.Sprintf(
+			"Cannot mark DAG run: %s", err.Error()))
+		return
+	}
+	drd, err := pdrd.schedApi.UIDagrunDetails(runId)
+	if err != nil {
+		pdrd.logger.Error("Cannot read DAG run details", "runId", runId, "err",
+			err.Error())
+		pdrd.renderActionsErr(w, runId, "Cannot read DAG run details")
+		return
+	}
+
+	pdrd.logger.Info("Marking DAG run", "runId", runId, "status", status)
+	setErr := setter.SetDagRunStatus(runId, runStatus)
+	user := requestUser(r, pdrd.config.UserHeader)
+	event := AuditEvent{
+		User:    user,
+		Action:  auditActionMarkRun,
+		RunId:   drd.RunId,
+		DagId:   drd.DagId,
+		Details: fmt.Sprintf("%s marked as %s", drd.Status, status),
+		Reason:  reason,
+	}
+	if setErr != nil {
+		event.Err = setErr.Error()
+	}
+	pdrd.audit.add(event)
+	if setErr != nil {
+		pdrd.logger.Error("Error while marking DAG run", "runId", runId,
+			"err", setErr.Error())
+		pdrd.renderActionsErr(w, runId, "Cannot set DAG run status")
+		return
+	}
+	pdrd.annotations.add(Annotation{
+		User:  user,
+		RunId: drd.RunId,
+		Text:  fmt.Sprintf("Marked as %s: %s", status, reason),
+	})
+	w.Header().Set("HX-Redirect", fmt.Sprintf("/dagruns/%d", runId))
+	w.WriteHeader(http.StatusOK)
+}
+
+// Checks if given task attempt exists in the DAG run and if it's the latest
+// attempt of the task. Only the latest attempts can be marked (CanMark).
+func markableAttempt(
+	drd api.UIDagrunDetails, taskId string, retry int,
+) (exists, latest bool) {
+	latestRetry := -1
+	for _, task := range drd.Tasks {
+		if task.TaskId != taskId {
+			continue
+		}
+		if task.Retry == retry {
+			exists = true
+		}
+		latestRetry = max(latestRetry, task.Retry)
+	}
+	return exists, exists && retry == latestRetry
+}
+
+func (pdrd *pageDagRunDetails) renderTaskMarkForm(
+	w http.ResponseWriter, form TaskMarkForm,
+) {
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	if err := pdrd.templates.Render(w, "task_mark_form", form); err != nil {
+		pdrd.logger.Error("Cannot render <task_mark_form>", "err", err.Error())
+	}
+}
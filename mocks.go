@@ -3,18 +3,121 @@ package ui
 import (
 	"encoding/json"
 	"fmt"
+	"hash/fnv"
 	"math/rand"
+	"slices"
+	"sync"
 	"time"
 
 	"github.com/ppacer/core/api"
 	"github.com/ppacer/core/dag"
+	"github.com/ppacer/core/dag/schedule"
 	"github.com/ppacer/core/scheduler"
 	"github.com/ppacer/core/timeutils"
 )
 
 // SchedulerMock mocks ppacer scheduler.API, so the UI can be run without
-// actual ppacer Scheduler running.
+// actual ppacer Scheduler running. It also implements optional UI extensions
+// of scheduler.API. SchedulerMock should be created using NewSchedulerMock.
 type SchedulerMock struct {
+	state *mockState
+}
+
+// NewSchedulerMock creates new instance of SchedulerMock with empty state.
+func NewSchedulerMock() SchedulerMock {
+	return SchedulerMock{state: newMockState()}
+}
+
+// mockState keeps mutable state of SchedulerMock, like DAG runs triggered via
+// the UI.
+type mockState struct {
+	sync.Mutex
+	triggered     map[string]map[string]struct{}
+	runStatuses   map[int]string
+	cancelledRuns map[int]struct{}
+	taskStatuses  map[mockTaskKey]string
+	clearedTasks  map[mockTaskKey]struct{}
+	pausedDags    map[string]DagState
+	schedState    scheduler.State
+}
+
+// Task attempt within a mocked DAG run.
+type mockTaskKey struct {
+	RunId  int
+	TaskId string
+	Retry  int
+}
+
+func newMockState() *mockState {
+	return &mockState{
+		triggered:     map[string]map[string]struct{}{},
+		runStatuses:   map[int]string{},
+		cancelledRuns: map[int]struct{}{},
+		taskStatuses:  map[mockTaskKey]string{},
+		clearedTasks:  map[mockTaskKey]struct{}{},
+		pausedDags:    map[string]DagState{},
+		schedState:    scheduler.StateRunning,
+	}
+}
+
+// Overrides randomly generated statuses of DAG run and its tasks with
+// statuses set by actions performed via the UI.
+func (s *mockState) applyOverrides(drd *api.UIDagrunDetails) {
+	s.Lock()
+	defer s.Unlock()
+	runId := int(drd.RunId)
+	if status, exists := s.runStatuses[runId]; exists {
+		drd.Status = status
+	}
+	_, cancelled := s.cancelledRuns[runId]
+	latest := latestAttempts(drd.Tasks)
+	for i, task := range drd.Tasks {
+		key := mockTaskKey{RunId: runId, TaskId: task.TaskId, Retry: task.Retry}
+		if status, exists := s.taskStatuses[key]; exists {
+			drd.Tasks[i].Status = status
+			drd.Tasks[i].TaskNoStarted = false
+			continue
+		}
+		cleared := mockTaskKey{RunId: runId, TaskId: task.TaskId}
+		if _, exists := s.clearedTasks[cleared]; exists &&
+			latest[task.TaskId].Retry == task.Retry {
+			drd.Tasks[i].Status = dag.TaskScheduled.String()
+			continue
+		}
+		if cancelled && isActiveTaskStatus(task.Status) {
+			drd.Tasks[i].Status = dag.TaskFailed.String()
+		}
+	}
+}
+
+// Overrides randomly generated status of a single task attempt.
+func (s *mockState) applyTaskOverride(runId int, task *api.UIDagrunTask) {
+	s.Lock()
+	defer s.Unlock()
+	key := mockTaskKey{RunId: runId, TaskId: task.TaskId, Retry: task.Retry}
+	if status, exists := s.taskStatuses[key]; exists {
+		task.Status = status
+		return
+	}
+	cleared := mockTaskKey{RunId: runId, TaskId: task.TaskId}
+	if _, exists := s.clearedTasks[cleared]; exists {
+		task.Status = dag.TaskScheduled.String()
+		return
+	}
+	if _, cancelled := s.cancelledRuns[runId]; cancelled &&
+		isActiveTaskStatus(task.Status) {
+		task.Status = dag.TaskFailed.String()
+	}
+}
+
+// State used by SchedulerMock which wasn't created using NewSchedulerMock.
+var defaultMockState = newMockState()
+
+func (sm SchedulerMock) data() *mockState {
+	if sm.state == nil {
+		return defaultMockState
+	}
+	return sm.state
 }
 
 // GetTask return random TaskToExec.
@@ -32,16 +135,41 @@ func (sm SchedulerMock) GetTask() (api.TaskToExec, error) {
 	return tte, nil
 }
 
-// UpsertTaskStatus does nothing and return nil error every time.
+// UpsertTaskStatus sets status of mocked DAG run task attempt. DAG run is
+// determined based on tte.ExecTs, the same way as it's generated in
+// UIDagrunDetails.
 func (sm SchedulerMock) UpsertTaskStatus(
 	tte api.TaskToExec, status dag.TaskStatus, taskErr error,
 ) error {
+	runId, err := mockRunId(tte.ExecTs)
+	if err != nil {
+		return err
+	}
+	state := sm.data()
+	state.Lock()
+	defer state.Unlock()
+	key := mockTaskKey{RunId: runId, TaskId: tte.TaskId, Retry: tte.Retry}
+	state.taskStatuses[key] = status.String()
+	delete(state.clearedTasks, mockTaskKey{RunId: runId, TaskId: tte.TaskId})
 	return nil
 }
 
-// GetState always returns RUNNING state.
+// GetState returns mocked Scheduler state, which is RUNNING unless it was
+// changed using SetState.
 func (sm SchedulerMock) GetState() (scheduler.State, error) {
-	return scheduler.StateRunning, nil
+	state := sm.data()
+	state.Lock()
+	defer state.Unlock()
+	return state.schedState, nil
+}
+
+// SetState sets mocked Scheduler state. It's meant for checking how the UI
+// behaves, when the Scheduler doesn't accept new work.
+func (sm SchedulerMock) SetState(s scheduler.State) {
+	state := sm.data()
+	state.Lock()
+	defer state.Unlock()
+	state.schedState = s
 }
 
 func (sm SchedulerMock) TriggerDagRun(in api.DagRunTriggerInput) error {
@@ -54,6 +182,70 @@ func (sm SchedulerMock) RestartDagRun(in api.DagRunRestartInput) error {
 	return nil
 }
 
+// Schedules of mocked DAGs. DAGs without schedule are mapped to nil.
+var mockDagSchedules = map[string]schedule.Schedule{
+	"sample_dag": schedule.NewFixed(
+		time.Date(2024, 1, 1, 0, 0, 0, 0, time.Local), time.Hour,
+	),
+	"mock_dag":                    schedule.Daily(5, 0),
+	"sample_mock_longer_name_dag": nil,
+	"linked_list": schedule.NewFixed(
+		time.Date(2024, 1, 1, 0, 0, 0, 0, time.Local), 10*time.Minute,
+	),
+	"complex_dag": schedule.NewCron().AtMinutes(0, 15, 30, 45),
+}
+
+// IDs of mocked DAGs ordered by DAG ID.
+var mockDagIds = []string{
+	"complex_dag",
+	"linked_list",
+	"mock_dag",
+	"sample_dag",
+	"sample_mock_longer_name_dag",
+}
+
+// DagSchedule returns schedule of one of mocked DAGs.
+func (sm SchedulerMock) DagSchedule(dagId string) (schedule.Schedule, error) {
+	sched, exists := mockDagSchedules[dagId]
+	if !exists {
+		return nil, fmt.Errorf("DAG %s does not exist", dagId)
+	}
+	return sched, nil
+}
+
+// DagRunExists checks if DAG run was triggered using TriggerDagRunAt before.
+// Additionally every DAG run before 2024-01-01 is treated as existing one.
+func (sm SchedulerMock) DagRunExists(dagId string, execTs time.Time) (bool, error) {
+	if execTs.Before(time.Date(2024, 1, 1, 0, 0, 0, 0, time.Local)) {
+		return true, nil
+	}
+	state := sm.data()
+	state.Lock()
+	defer state.Unlock()
+	_, exists := state.triggered[dagId][timeutils.ToString(execTs)]
+	return exists, nil
+}
+
+// TriggerDagRunAt registers DAG run after a random delay. Every 20th call, on
+// average, fails.
+func (sm SchedulerMock) TriggerDagRunAt(
+	in api.DagRunTriggerInput, execTs time.Time,
+) error {
+	time.Sleep(time.Duration(rand.Intn(500)+50) * time.Millisecond)
+	if rand.Intn(20) == 0 {
+		return fmt.Errorf("mocked failure of triggering DAG run %s at %s",
+			in.DagId, execTs)
+	}
+	state := sm.data()
+	state.Lock()
+	defer state.Unlock()
+	if _, exists := state.triggered[in.DagId]; !exists {
+		state.triggered[in.DagId] = map[string]struct{}{}
+	}
+	state.triggered[in.DagId][timeutils.ToString(execTs)] = struct{}{}
+	return nil
+}
+
 // UIDagrunStats returns random stats on DAG runs.
 func (sm SchedulerMock) UIDagrunStats() (api.UIDagrunStats, error) {
 	return api.UIDagrunStats{
@@ -82,6 +274,44 @@ func (sm SchedulerMock) UIDagrunLatest(n int) (api.UIDagrunList, error) {
 	return list, nil
 }
 
+// UIDagrunQuery returns random DAG runs matching given query. Run IDs are
+// consecutive and execution timestamps are decreasing by one hour.
+func (sm SchedulerMock) UIDagrunQuery(q DagRunQuery) (api.UIDagrunList, error) {
+	dagIds := []string{"sample_dag", "mock_dag", "sample_mock_longer_name_dag"}
+	limit := q.Limit
+	if limit <= 0 {
+		limit = 100
+	}
+	runId := int64(rand.Intn(1000) + 1000)
+	if q.BeforeRunId > 0 {
+		runId = q.BeforeRunId - 1
+	}
+	execTs := time.Now()
+	if !q.Until.IsZero() {
+		execTs = q.Until
+	}
+
+	list := make(api.UIDagrunList, 0, limit)
+	for len(list) < limit && runId > 0 {
+		if !q.Since.IsZero() && execTs.Before(q.Since) {
+			break
+		}
+		dagId := q.DagId
+		if dagId == "" {
+			dagId = dagIds[rand.Intn(len(dagIds))]
+		}
+		row := randomDagrunRow(int(runId), dagId)
+		row.ExecTs = api.ToTimestamp(execTs)
+		if len(q.Statuses) > 0 {
+			row.Status = q.Statuses[rand.Intn(len(q.Statuses))]
+		}
+		list = append(list, row)
+		runId--
+		execTs = execTs.Add(-time.Hour)
+	}
+	return list, nil
+}
+
 // UIDagrunDetails returns random data on given DAG run details.
 func (sm SchedulerMock) UIDagrunDetails(runId int) (api.UIDagrunDetails, error) {
 	now := time.Now()
@@ -92,16 +322,31 @@ func (sm SchedulerMock) UIDagrunDetails(runId int) (api.UIDagrunDetails, error)
 		"linked_list",
 		"complex_dag",
 	}
-	dagId := dagIds[rand.Intn(len(dagIds))]
+	// DAG is deterministic for given DAG run, so actions performed on the
+	// DAG run tasks can be observed.
+	dagId := dagIds[mockSeed(runId, "", 0)%int64(len(dagIds))]
+	execTs := mockExecTs(runId)
 	drd := api.UIDagrunDetails{
 		RunId:     int64(runId),
 		DagId:     dagId,
-		ExecTs:    api.ToTimestamp(now),
-		ExecTsRaw: now.Format(time.RFC3339),
+		ExecTs:    api.ToTimestamp(execTs),
+		ExecTsRaw: execTs.Format(time.RFC3339),
 		Status:    randomStatus(),
 		Duration:  end.Sub(now).String(),
-		Tasks:     randomDagrunTasks(dagId),
+		Tasks:     randomDagrunTasks(runId, dagId),
+	}
+	for i, task := range drd.Tasks {
+		if task.TaskNoStarted {
+			continue
+		}
+		drd.Tasks[i].TaskLogs = mockTaskLogs(
+			runId, task.TaskId, task.Retry, 0, mockTaskLogsFirstPage,
+		)
+		if task.Config != "" {
+			drd.Tasks[i].Config = mockTaskConfig(runId, task.TaskId, task.Retry)
+		}
 	}
+	sm.data().applyOverrides(&drd)
 	return drd, nil
 }
 
@@ -110,7 +355,6 @@ func (sm SchedulerMock) UIDagrunTaskDetails(
 ) (api.UIDagrunTask, error) {
 	startTs := time.Now()
 	taskEnd := startTs.Add(time.Duration(rand.Intn(10000)) * time.Millisecond)
-	nLogs := rand.Intn(15)
 
 	t := api.UIDagrunTask{
 		TaskId:        taskId,
@@ -120,27 +364,196 @@ func (sm SchedulerMock) UIDagrunTaskDetails(
 		Pos:           api.TaskPos{Depth: 2, Width: rand.Intn(100)},
 		Status:        randomStatus(),
 		Duration:      taskEnd.Sub(startTs).String(),
-		Config:        `{X:10,Y:"value"}`,
-		TaskLogs:      randomTaskLogs(nLogs),
+		Config:        mockTaskConfig(runId, taskId, retry),
+		TaskLogs: mockTaskLogs(
+			runId, taskId, retry, 0, mockTaskLogsFirstPage,
+		),
 	}
+	sm.data().applyTaskOverride(runId, &t)
 	return t, nil
 }
 
-func randomDagrunTasks(dagId string) []api.UIDagrunTask {
-	length := rand.Intn(10) + 3
+// CancelDagRun marks mocked DAG run and its running and scheduled tasks as
+// failed.
+func (sm SchedulerMock) CancelDagRun(runId int) error {
+	state := sm.data()
+	state.Lock()
+	defer state.Unlock()
+	state.runStatuses[runId] = dag.RunFailed.String()
+	state.cancelledRuns[runId] = struct{}{}
+	return nil
+}
+
+// ClearTasks marks the latest attempts of given mocked DAG run tasks as
+// scheduled and the DAG run as running.
+func (sm SchedulerMock) ClearTasks(runId int, taskIds []string) error {
+	state := sm.data()
+	state.Lock()
+	defer state.Unlock()
+	state.runStatuses[runId] = dag.RunRunning.String()
+	delete(state.cancelledRuns, runId)
+	for _, taskId := range taskIds {
+		for key := range state.taskStatuses {
+			if key.RunId == runId && key.TaskId == taskId {
+				delete(state.taskStatuses, key)
+			}
+		}
+		state.clearedTasks[mockTaskKey{RunId: runId, TaskId: taskId}] = struct{}{}
+	}
+	return nil
+}
+
+// CancelTask marks mocked DAG run task attempt as failed.
+func (sm SchedulerMock) CancelTask(runId int, taskId string, retry int) error {
+	state := sm.data()
+	state.Lock()
+	defer state.Unlock()
+	key := mockTaskKey{RunId: runId, TaskId: taskId, Retry: retry}
+	state.taskStatuses[key] = dag.TaskFailed.String()
+	return nil
+}
+
+// SetDagRunStatus sets status of mocked DAG run.
+func (sm SchedulerMock) SetDagRunStatus(runId int, status dag.RunStatus) error {
+	state := sm.data()
+	state.Lock()
+	defer state.Unlock()
+	state.runStatuses[runId] = status.String()
+	return nil
+}
+
+// UIDagStates returns states of mocked DAGs.
+func (sm SchedulerMock) UIDagStates() ([]DagState, error) {
+	state := sm.data()
+	state.Lock()
+	defer state.Unlock()
+	states := make([]DagState, 0, len(mockDagIds))
+	for _, dagId := range mockDagIds {
+		if paused, exists := state.pausedDags[dagId]; exists {
+			states = append(states, paused)
+			continue
+		}
+		states = append(states, DagState{DagId: dagId})
+	}
+	return states, nil
+}
+
+// PauseDag marks mocked DAG as paused.
+func (sm SchedulerMock) PauseDag(dagId, user, reason string) error {
+	if !slices.Contains(mockDagIds, dagId) {
+		return fmt.Errorf("DAG %s does not exist", dagId)
+	}
+	state := sm.data()
+	state.Lock()
+	defer state.Unlock()
+	if _, exists := state.pausedDags[dagId]; exists {
+		return fmt.Errorf("DAG %s is already paused", dagId)
+	}
+	state.pausedDags[dagId] = DagState{
+		DagId:       dagId,
+		Paused:      true,
+		PausedBy:    user,
+		PausedSince: time.Now(),
+		PauseReason: reason,
+	}
+	return nil
+}
+
+// UnpauseDag marks mocked DAG as not paused.
+func (sm SchedulerMock) UnpauseDag(dagId, _ string) error {
+	state := sm.data()
+	state.Lock()
+	defer state.Unlock()
+	delete(state.pausedDags, dagId)
+	return nil
+}
+
+// UIDagrunQueue returns random DAG runs waiting in the queue. Items are
+// enqueued every few seconds, the oldest first.
+func (sm SchedulerMock) UIDagrunQueue() ([]QueuedDagRun, error) {
+	n := rand.Intn(50)
+	now := time.Now()
+	queue := make([]QueuedDagRun, n)
+	for i := range queue {
+		enqueuedAt := now.Add(-time.Duration(n-i) * 5 * time.Second)
+		queue[i] = QueuedDagRun{
+			DagId:      mockDagIds[rand.Intn(len(mockDagIds))],
+			ExecTs:     enqueuedAt.Truncate(time.Minute),
+			EnqueuedAt: enqueuedAt,
+		}
+	}
+	return queue, nil
+}
+
+// UITaskQueue returns random tasks waiting in the queue. Tasks of
+// linked_list DAG are stuck in the head of the queue for hours, the
+// remaining tasks were enqueued within the last minutes.
+func (sm SchedulerMock) UITaskQueue() ([]QueuedTask, error) {
+	stuck, n := rand.Intn(100), rand.Intn(200)
+	now := time.Now()
+	queue := make([]QueuedTask, 0, stuck+n)
+	for i := 0; i < stuck; i++ {
+		enqueuedAt := now.Add(-time.Duration(stuck-i) * 3 * time.Minute)
+		queue = append(queue, QueuedTask{
+			DagId:      "linked_list",
+			ExecTs:     enqueuedAt.Truncate(time.Hour),
+			TaskId:     fmt.Sprintf("Step%d", rand.Intn(10)),
+			EnqueuedAt: enqueuedAt,
+		})
+	}
+	for i := 0; i < n; i++ {
+		enqueuedAt := now.Add(-time.Duration(n-i) * time.Second)
+		queue = append(queue, QueuedTask{
+			DagId:      mockDagIds[rand.Intn(len(mockDagIds))],
+			ExecTs:     enqueuedAt.Truncate(time.Minute),
+			TaskId:     fmt.Sprintf("task_%d", rand.Intn(10)),
+			Retry:      rand.Intn(2),
+			EnqueuedAt: enqueuedAt,
+		})
+	}
+	return queue, nil
+}
+
+// DagTaskParents returns parents of tasks for mocked DAGs. Structure is
+// consistent with tasks returned by UIDagrunDetails.
+func (sm SchedulerMock) DagTaskParents(dagId string) (map[string][]string, error) {
+	parents := make(map[string][]string)
 	switch dagId {
 	case "sample_dag":
-		return randomDagrunTasksSampleDag()
+		for i := 1; i <= 5; i++ {
+			taskId := fmt.Sprintf("task_2%d", i)
+			parents[taskId] = []string{"start"}
+			parents["finish"] = append(parents["finish"], taskId)
+		}
+	case "linked_list":
+		for i := 2; i <= 13; i++ {
+			parents[fmt.Sprintf("task_%d", i)] = []string{
+				fmt.Sprintf("task_%d", i-1),
+			}
+		}
+	}
+	return parents, nil
+}
+
+// Generates tasks of given DAG run. Tasks, their attempts and statuses are
+// deterministic for given DAG run, so task attempts shown on the page can be
+// acted upon.
+func randomDagrunTasks(runId int, dagId string) []api.UIDagrunTask {
+	r := rand.New(rand.NewSource(mockSeed(runId, "tasks", 0)))
+	length := r.Intn(10) + 3
+	switch dagId {
+	case "sample_dag":
+		return randomDagrunTasksSampleDag(r)
 	case "linked_list":
-		return randomDagrunTasksLinkedList(length, rand.Intn(length+1))
+		return randomDagrunTasksLinkedList(r, length, r.Intn(length+1))
 	default:
 	}
 	return []api.UIDagrunTask{}
 }
 
-func randomDagrunTasksSampleDag() []api.UIDagrunTask {
+func randomDagrunTasksSampleDag(r *rand.Rand) []api.UIDagrunTask {
 	startTs := time.Now()
-	d2l := rand.Intn(5) + 1
+	d2l := r.Intn(5) + 1
 	tasks := make([]api.UIDagrunTask, 0, d2l+2)
 
 	start := api.UIDagrunTask{
@@ -181,11 +594,14 @@ func randomDagrunTasksSampleDag() []api.UIDagrunTask {
 			Status:        dag.TaskNoStatus.String(),
 		}
 		if !tasksNotStarted {
-			t.Status = randomStatus()
-			taskEnd := startTs.Add(time.Duration(rand.Intn(10000)) * time.Millisecond)
+			t.Status = mockStatus(r.Intn(10))
+			taskEnd := startTs.Add(time.Duration(r.Intn(10000)) * time.Millisecond)
 			t.Duration = taskEnd.Sub(startTs).String()
 			t.Config = `{X:10,Y:"value"}`
-			t.TaskLogs = randomTaskLogs(rand.Intn(5))
+			if r.Intn(3) == 0 {
+				tasks = append(tasks, failedAttempt(t))
+				t.Retry++
+			}
 		}
 		tasks = append(tasks, t)
 	}
@@ -193,7 +609,16 @@ func randomDagrunTasksSampleDag() []api.UIDagrunTask {
 	return tasks
 }
 
-func randomDagrunTasksLinkedList(length int, tasksDone int) []api.UIDagrunTask {
+// Prepares failed attempt of given task, which preceded given one.
+func failedAttempt(task api.UIDagrunTask) api.UIDagrunTask {
+	failed := task
+	failed.Status = dag.TaskFailed.String()
+	return failed
+}
+
+func randomDagrunTasksLinkedList(
+	r *rand.Rand, length int, tasksDone int,
+) []api.UIDagrunTask {
 	start := time.Now()
 	tasks := make([]api.UIDagrunTask, 0, length)
 	tasksNotStarted := false
@@ -211,37 +636,111 @@ func randomDagrunTasksLinkedList(length int, tasksDone int) []api.UIDagrunTask {
 			Status:        dag.TaskNoStatus.String(),
 		}
 		if !tasksNotStarted {
-			task.Status = randomStatus()
-			taskEnd := start.Add(time.Duration(rand.Intn(10000)) * time.Millisecond)
+			task.Status = mockStatus(r.Intn(10))
+			taskEnd := start.Add(time.Duration(r.Intn(10000)) * time.Millisecond)
 			task.Duration = taskEnd.Sub(start).String()
 			task.Config = `{X:10,Y:"value"}`
-			task.TaskLogs = randomTaskLogs(rand.Intn(15))
 		}
 		tasks = append(tasks, task)
 	}
 	return tasks
 }
 
-func randomTaskLogs(length int) api.UITaskLogs {
-	records := make([]api.UITaskLogRecord, 0, length)
+// Number of log records returned for each task by mocked UIDagrunDetails and
+// UIDagrunTaskDetails.
+const mockTaskLogsFirstPage = 25
 
-	for i := 0; i < length; i++ {
-		record := api.UITaskLogRecord{
-			InsertTs:       api.ToTimestamp(time.Now()),
-			Level:          randomLogLevel(),
-			Message:        randomString(10, 200),
-			AttributesJson: randomLogAttr(),
+// UIDagrunTaskLogs returns window of log records, which are deterministic for
+// given task attempt.
+func (sm SchedulerMock) UIDagrunTaskLogs(
+	runId int, taskId string, retry int, offset, limit int,
+) (api.UITaskLogs, error) {
+	return mockTaskLogs(runId, taskId, retry, offset, limit), nil
+}
+
+// Generates window of log records for given task attempt. Number of records
+// and their content depends only on the task attempt and record position.
+func mockTaskLogs(
+	runId int, taskId string, retry int, offset, limit int,
+) api.UITaskLogs {
+	seed := mockSeed(runId, taskId, retry)
+	total := int(5 + seed%1500)
+	start := time.Now().Truncate(24 * time.Hour).
+		Add(time.Duration(seed%3600) * time.Second)
+	end := min(total, offset+limit)
+	records := make([]api.UITaskLogRecord, 0, max(0, end-offset))
+
+	// Attempts of the same task share most of log records, to make retries
+	// comparable.
+	baseSeed := mockSeed(runId, taskId, 0)
+	for i := offset; i < end; i++ {
+		recordSeed := baseSeed + int64(i)
+		if (seed+int64(i))%5 == 0 {
+			recordSeed = seed + int64(i)
 		}
-		records = append(records, record)
+		r := rand.New(rand.NewSource(recordSeed))
+		ts := start.Add(time.Duration(i) * 150 * time.Millisecond)
+		records = append(records, api.UITaskLogRecord{
+			InsertTs:       api.ToTimestamp(ts),
+			Level:          randomLogLevel(r),
+			Message:        fmt.Sprintf("[%d] %s", i+1, randomString(r, 10, 200)),
+			AttributesJson: randomLogAttr(r),
+		})
 	}
 
 	return api.UITaskLogs{
-		LogRecordsCount: length,
-		LoadedRecords:   length,
+		LogRecordsCount: total,
+		LoadedRecords:   len(records),
 		Records:         records,
 	}
 }
 
+// Prepares config of given task attempt, which is deterministic for given
+// task attempt. Half of tasks have JSON config, the other half has Go
+// struct-like config.
+func mockTaskConfig(runId int, taskId string, retry int) string {
+	r := rand.New(rand.NewSource(mockSeed(runId, taskId, retry)))
+	regions := []string{"eu-west-1", "us-east-1", "ap-south-1"}
+	timeout := 10 * (r.Intn(6) + 1)
+	region := regions[r.Intn(len(regions))]
+	debug := retry > 0
+	if len(taskId)%2 == 0 {
+		return fmt.Sprintf(
+			`{"X":10,"Y":"value","timeout":"%ds","retries":%d,"env":{"region":"%s","debug":%t},"tags":["etl","%s"]}`,
+			timeout, retry+2, region, debug, taskId,
+		)
+	}
+	return fmt.Sprintf(
+		`{X:10 Y:value Timeout:%ds Retries:%d Env:{Region:%s Debug:%t} Tags:[etl %s]}`,
+		timeout, retry+2, region, debug, taskId,
+	)
+}
+
+// Execution timestamps of mocked DAG runs are deterministic, DAG runs are
+// spread every mockExecInterval starting from mockBaseTs.
+var mockBaseTs = time.Now().UTC().Truncate(24*time.Hour).AddDate(0, 0, -7)
+
+const mockExecInterval = 10 * time.Minute
+
+func mockExecTs(runId int) time.Time {
+	return mockBaseTs.Add(time.Duration(runId) * mockExecInterval)
+}
+
+// Determines mocked DAG run ID based on its execution timestamp.
+func mockRunId(execTs string) (int, error) {
+	ts, err := time.Parse(time.RFC3339, execTs)
+	if err != nil {
+		return -1, fmt.Errorf("invalid execTs %q: %w", execTs, err)
+	}
+	return int(ts.Sub(mockBaseTs) / mockExecInterval), nil
+}
+
+func mockSeed(runId int, taskId string, retry int) int64 {
+	h := fnv.New64a()
+	fmt.Fprintf(h, "%d/%s/%d", runId, taskId, retry)
+	return int64(h.Sum64() >> 1)
+}
+
 func randomStatusCounts(interval int) api.StatusCounts {
 	return api.StatusCounts{
 		Success:   rand.Intn(interval+1) + interval/4,
@@ -277,21 +776,25 @@ func randomLocation() *time.Location {
 }
 
 func randomStatus() string {
-	r := rand.Intn(10)
-	if r > 8 {
+	return mockStatus(rand.Intn(10))
+}
+
+// Maps number from [0, 10) into status, the same way as randomStatus.
+func mockStatus(n int) string {
+	if n > 8 {
 		return dag.RunFailed.String()
-	} else if r > 7 {
+	} else if n > 7 {
 		return dag.RunSuccess.String()
 	}
 	return dag.RunRunning.String()
 }
 
-func randomLogLevel() string {
-	r := rand.Intn(10)
-	if r > 8 {
+func randomLogLevel(r *rand.Rand) string {
+	n := r.Intn(10)
+	if n > 8 {
 		return "ERROR"
 	}
-	if r > 6 {
+	if n > 6 {
 		return "WARN"
 	}
 	return "INFO"
@@ -301,25 +804,35 @@ const charset = `                 abcdefghij
 klmnopqrstuvw       xyzABCDEFGH       IJKLMNOPQR  STUVWXYZ0123456789"
 `
 
-func randomString(minLen, maxLen int) string {
-	l := rand.Intn(maxLen-minLen+1) + minLen
+func randomString(r *rand.Rand, minLen, maxLen int) string {
+	l := r.Intn(maxLen-minLen+1) + minLen
 	word := make([]byte, l)
 	for i := 0; i < l; i++ {
-		word[i] = charset[rand.Intn(len(charset))]
+		word[i] = charset[r.Intn(len(charset))]
 	}
 	return string(word)
 }
 
-func randomLogAttr() string {
-	l := rand.Intn(3)
+func randomLogAttr(r *rand.Rand) string {
+	components := []string{"db", "http", "cache", "worker"}
+	methods := []string{"GET", "POST", "PUT"}
 	attr := make(map[string]any)
-	for i := 0; i < l; i++ {
-		if i%2 == 0 {
-			attr[randomString(1, 10)] = rand.Intn(100)
-		} else {
-			attr[randomString(1, 10)] = randomString(10, 30)
+	if r.Intn(2) == 0 {
+		attr["component"] = components[r.Intn(len(components))]
+	}
+	if r.Intn(3) == 0 {
+		attr["batch"] = r.Intn(10)
+	}
+	if r.Intn(4) == 0 {
+		attr["request"] = map[string]any{
+			"method": methods[r.Intn(len(methods))],
+			"path":   "/" + randomString(r, 3, 12),
+			"took":   fmt.Sprintf("%dms", r.Intn(500)),
 		}
 	}
+	if r.Intn(10) == 0 {
+		attr["payload"] = randomString(r, 80, 200)
+	}
 	json, _ := json.Marshal(attr)
 	return string(json)
 }
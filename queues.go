@@ -0,0 +1,314 @@
+package ui
+
+import (
+	"log/slog"
+	"net/http"
+	"sort"
+	"time"
+
+	"github.com/ppacer/core/scheduler"
+)
+
+const (
+	queuesErr = "queuesErr"
+
+	// Maximum number of items of a single queue listed on "Queues" page.
+	// Summaries are computed over all items.
+	maxQueueRows = 500
+
+	// Number of DAGs with the most queued items shown in queue summary.
+	maxQueueSummaryDags = 10
+
+	// Minimal percent of queued items of a single DAG, which is considered
+	// as a stuck DAG rather than overall slowness.
+	dominantDagPercent = 50
+
+	// Minimal number of queued items, for which a dominant DAG is reported.
+	// Few queued items are usually processed soon, even if they all belong
+	// to a single DAG.
+	minDominantQueueItems = 5
+)
+
+// Buckets of queued items age histograms. The last bucket is unbounded.
+var queueAgeBuckets = []struct {
+	Label string
+	Upper time.Duration
+}{
+	{"< 1m", time.Minute},
+	{"1m - 5m", 5 * time.Minute},
+	{"5m - 15m", 15 * time.Minute},
+	{"15m - 1h", time.Hour},
+	{"1h - 6h", 6 * time.Hour},
+	{"> 6h", 0},
+}
+
+// Type pageQueues keeps data required for "Queues" (/queues) page.
+type pageQueues struct {
+	templates *templates
+	schedApi  scheduler.API
+	logger    *slog.Logger
+}
+
+func newPageQueues(
+	schedApi scheduler.API, tmpl *templates, logger *slog.Logger,
+) *pageQueues {
+	if logger == nil {
+		logger = defaultLogger()
+	}
+	return &pageQueues{
+		templates: tmpl,
+		schedApi:  schedApi,
+		logger:    logger,
+	}
+}
+
+// QueuesView represents data rendered on "Queues" page. ExpectedTasks and
+// ExpectedRuns are queue lengths shown on statistics cards, which linked to
+// the page.
+type QueuesView struct {
+	Page          string
+	DagRuns       []QueuedDagRun
+	Tasks         []QueuedTask
+	DagRunsStats  QueueSummary
+	TasksStats    QueueSummary
+	ExpectedTasks int
+	ExpectedRuns  int
+	Supported     bool
+	Errors        map[string]string
+	Version       string
+}
+
+// QueueCount compares queue length from statistics with the number of listed
+// queue items.
+type QueueCount struct {
+	Expected int
+	Listed   int
+}
+
+// TaskCount returns expected and listed number of queued tasks.
+func (v QueuesView) TaskCount() QueueCount {
+	return QueueCount{Expected: v.ExpectedTasks, Listed: len(v.Tasks)}
+}
+
+// DagRunCount returns expected and listed number of queued DAG runs.
+func (v QueuesView) DagRunCount() QueueCount {
+	return QueueCount{Expected: v.ExpectedRuns, Listed: len(v.DagRuns)}
+}
+
+// TaskRows returns queued tasks listed in the table.
+func (v QueuesView) TaskRows() []QueuedTask {
+	return v.Tasks[:min(len(v.Tasks), maxQueueRows)]
+}
+
+// DagRunRows returns queued DAG runs listed in the table.
+func (v QueuesView) DagRunRows() []QueuedDagRun {
+	return v.DagRuns[:min(len(v.DagRuns), maxQueueRows)]
+}
+
+// ExecTsDisplay returns formatted execution timestamp of the DAG run.
+func (q QueuedDagRun) ExecTsDisplay() string {
+	return q.ExecTs.Format(auditDisplayFormat)
+}
+
+// EnqueuedAtDisplay returns formatted time when the DAG run was enqueued.
+func (q QueuedDagRun) EnqueuedAtDisplay() string {
+	return formatEnqueuedAt(q.EnqueuedAt)
+}
+
+// AgeDisplay returns for how long the DAG run is waiting in the queue.
+func (q QueuedDagRun) AgeDisplay() string { return formatQueueAge(q.EnqueuedAt) }
+
+// ExecTsDisplay returns formatted execution timestamp of the task DAG run.
+func (q QueuedTask) ExecTsDisplay() string {
+	return q.ExecTs.Format(auditDisplayFormat)
+}
+
+// EnqueuedAtDisplay returns formatted time when the task was enqueued.
+func (q QueuedTask) EnqueuedAtDisplay() string {
+	return formatEnqueuedAt(q.EnqueuedAt)
+}
+
+// AgeDisplay returns for how long the task is waiting in the queue.
+func (q QueuedTask) AgeDisplay() string { return formatQueueAge(q.EnqueuedAt) }
+
+func formatEnqueuedAt(ts time.Time) string {
+	if ts.IsZero() {
+		return "-"
+	}
+	return ts.Format(auditDisplayFormat)
+}
+
+func formatQueueAge(ts time.Time) string {
+	if ts.IsZero() {
+		return "-"
+	}
+	return roundDuration(time.Since(ts)).String()
+}
+
+// QueueAgeBucket represents a single bar of queued items age histogram.
+type QueueAgeBucket struct {
+	Label   string
+	Count   int
+	Percent int
+}
+
+// QueueDagSummary represents queued items of a single DAG.
+type QueueDagSummary struct {
+	DagId   string
+	Count   int
+	Percent int
+	Oldest  time.Duration
+}
+
+// OldestDisplay returns age of the oldest queued item of the DAG.
+func (s QueueDagSummary) OldestDisplay() string {
+	return roundDuration(s.Oldest).String()
+}
+
+// QueueSummary aggregates ages of queued items. Items without enqueue time
+// are counted in NoAge and skipped in age statistics.
+type QueueSummary struct {
+	Items   int
+	NoAge   int
+	Oldest  time.Duration
+	Median  time.Duration
+	Buckets []QueueAgeBucket
+	Dags    []QueueDagSummary
+}
+
+// HasAges checks if any of queued items has known enqueue time.
+func (s QueueSummary) HasAges() bool { return s.Items > s.NoAge }
+
+// OldestDisplay returns age of the oldest queued item.
+func (s QueueSummary) OldestDisplay() string {
+	return roundDuration(s.Oldest).String()
+}
+
+// MedianDisplay returns median age of queued items.
+func (s QueueSummary) MedianDisplay() string {
+	return roundDuration(s.Median).String()
+}
+
+// Dominant returns DAG which holds the majority of queued items, which
+// usually means that the DAG is stuck, rather than the Scheduler is slow in
+// general. It returns nil, when there is no such DAG or there are less than
+// minDominantQueueItems queued items.
+func (s QueueSummary) Dominant() *QueueDagSummary {
+	if s.Items < minDominantQueueItems || len(s.Dags) == 0 ||
+		s.Dags[0].Percent < dominantDagPercent {
+		return nil
+	}
+	return &s.Dags[0]
+}
+
+// Queued item reduced to fields used in queue summary.
+type queueItem struct {
+	DagId      string
+	EnqueuedAt time.Time
+}
+
+// summarizeQueue computes age histogram and per DAG summary of queued items.
+// DAGs are ordered by the number of queued items.
+func summarizeQueue(items []queueItem, now time.Time) QueueSummary {
+	summary := QueueSummary{
+		Items:   len(items),
+		Buckets: make([]QueueAgeBucket, len(queueAgeBuckets)),
+	}
+	for i, b := range queueAgeBuckets {
+		summary.Buckets[i].Label = b.Label
+	}
+	dags := make(map[string]*QueueDagSummary)
+	ages := make([]time.Duration, 0, len(items))
+	for _, item := range items {
+		dagSummary, exists := dags[item.DagId]
+		if !exists {
+			dagSummary = &QueueDagSummary{DagId: item.DagId}
+			dags[item.DagId] = dagSummary
+		}
+		dagSummary.Count++
+		if item.EnqueuedAt.IsZero() {
+			summary.NoAge++
+			continue
+		}
+		age := max(now.Sub(item.EnqueuedAt), 0)
+		ages = append(ages, age)
+		dagSummary.Oldest = max(dagSummary.Oldest, age)
+		summary.Oldest = max(summary.Oldest, age)
+		for i, b := range queueAgeBuckets {
+			if b.Upper == 0 || age < b.Upper {
+				summary.Buckets[i].Count++
+				break
+			}
+		}
+	}
+	if len(ages) > 0 {
+		summary.Median = medianDuration(ages)
+		for i := range summary.Buckets {
+			summary.Buckets[i].Percent = summary.Buckets[i].Count * 100 /
+				len(ages)
+		}
+	}
+
+	for _, dagSummary := range dags {
+		dagSummary.Percent = dagSummary.Count * 100 / len(items)
+		summary.Dags = append(summary.Dags, *dagSummary)
+	}
+	sort.Slice(summary.Dags, func(i, j int) bool {
+		a, b := summary.Dags[i], summary.Dags[j]
+		if a.Count != b.Count {
+			return a.Count > b.Count
+		}
+		return a.DagId < b.DagId
+	})
+	summary.Dags = summary.Dags[:min(len(summary.Dags), maxQueueSummaryDags)]
+	return summary
+}
+
+// Main handler for "Queues" page. It lists DAG runs and tasks currently
+// waiting in the Scheduler queues together with histograms of their age.
+func (pq *pageQueues) MainHandler(w http.ResponseWriter, r *http.Request) {
+	view := QueuesView{
+		Page:          "Runs",
+		ExpectedTasks: max(queryInt(r, "tasks", 0), 0),
+		ExpectedRuns:  max(queryInt(r, "runs", 0), 0),
+		Errors:        map[string]string{},
+		Version:       Version,
+	}
+	inspector, supported := pq.schedApi.(QueueInspector)
+	view.Supported = supported
+	if !supported {
+		view.Errors[queuesErr] = "Connected Scheduler does not support listing queues"
+	} else {
+		var err error
+		view.DagRuns, err = inspector.UIDagrunQueue()
+		if err != nil {
+			pq.logger.Error("Cannot read DAG runs queue", "err", err.Error())
+			view.Errors[queuesErr] = "Cannot read DAG runs queue: " +
+				err.Error()
+		}
+		view.Tasks, err = inspector.UITaskQueue()
+		if err != nil {
+			pq.logger.Error("Cannot read task queue", "err", err.Error())
+			view.Errors[queuesErr] = "Cannot read task queue: " + err.Error()
+		}
+	}
+
+	now := time.Now()
+	runItems := make([]queueItem, 0, len(view.DagRuns))
+	for _, q := range view.DagRuns {
+		runItems = append(runItems, queueItem{q.DagId, q.EnqueuedAt})
+	}
+	view.DagRunsStats = summarizeQueue(runItems, now)
+	taskItems := make([]queueItem, 0, len(view.Tasks))
+	for _, q := range view.Tasks {
+		taskItems = append(taskItems, queueItem{q.DagId, q.EnqueuedAt})
+	}
+	view.TasksStats = summarizeQueue(taskItems, now)
+
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	renderErr := pq.templates.Render(w, "page_queues", view)
+	if renderErr != nil {
+		pq.logger.Error("Cannot render <page_queues>", "err",
+			renderErr.Error())
+	}
+}
@@ -0,0 +1,201 @@
+package ui
+
+import (
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/ppacer/core/api"
+)
+
+const (
+	// Maximum number of DAG runs kept in the index of recently seen runs.
+	maxIndexedRuns = 5000
+
+	// Maximum number of task IDs kept in the index of recently seen runs.
+	maxIndexedTasks = 5000
+)
+
+// runIndex keeps DAG runs and tasks recently seen by the UI (in the latest
+// DAG runs list, history or DAG run details). It's used for search
+// suggestions, without querying the Scheduler on each keystroke. When the
+// index is full, the oldest seen entries are evicted. Seen DAG runs are also
+// persisted in the history store, when it's configured.
+type runIndex struct {
+	sync.RWMutex
+	maxRuns  int
+	maxTasks int
+	runs     map[int64]api.UIDagrunRow
+	runOrder []int64
+	tasks    map[indexedTaskKey]int64
+	taskKeys []indexedTaskKey
+	store    *historyStore
+}
+
+// Task ID within a DAG.
+type indexedTaskKey struct {
+	DagId  string
+	TaskId string
+}
+
+// TaskSuggestion represents a task recently seen in given DAG run.
+type TaskSuggestion struct {
+	DagId  string
+	TaskId string
+	RunId  int64
+}
+
+// Creates new runIndex with DAG runs most recently seen before, when history
+// store is configured.
+func newRunIndex(maxRuns, maxTasks int, store *historyStore) *runIndex {
+	ri := &runIndex{
+		maxRuns:  maxRuns,
+		maxTasks: maxTasks,
+		runs:     make(map[int64]api.UIDagrunRow),
+		tasks:    make(map[indexedTaskKey]int64),
+		store:    store,
+	}
+	rows, err := store.latestRuns(maxRuns)
+	if err != nil {
+		store.logger.Error("Cannot load DAG runs from history store", "err",
+			err.Error())
+	}
+	ri.insertRuns(rows)
+	return ri
+}
+
+// addRuns adds or updates given DAG runs in the index.
+func (ri *runIndex) addRuns(rows api.UIDagrunList) {
+	if ri == nil {
+		return
+	}
+	ri.insertRuns(rows)
+	ri.store.saveRuns(rows)
+}
+
+// Adds or updates given DAG runs in memory.
+func (ri *runIndex) insertRuns(rows api.UIDagrunList) {
+	ri.Lock()
+	defer ri.Unlock()
+	for _, row := range rows {
+		if _, exists := ri.runs[row.RunId]; !exists {
+			ri.runOrder = append(ri.runOrder, row.RunId)
+		}
+		ri.runs[row.RunId] = row
+	}
+	for len(ri.runOrder) > ri.maxRuns {
+		delete(ri.runs, ri.runOrder[0])
+		ri.runOrder = ri.runOrder[1:]
+	}
+}
+
+// addDagrun adds DAG run and its tasks, based on DAG run details.
+func (ri *runIndex) addDagrun(drd api.UIDagrunDetails) {
+	if ri == nil || drd.DagId == "" {
+		return
+	}
+	ri.Lock()
+	row, exists := ri.runs[drd.RunId]
+	if exists {
+		row.Status, row.Duration = drd.Status, drd.Duration
+		ri.runs[drd.RunId] = row
+	}
+	for _, task := range drd.Tasks {
+		key := indexedTaskKey{DagId: drd.DagId, TaskId: task.TaskId}
+		if _, exists := ri.tasks[key]; !exists {
+			ri.taskKeys = append(ri.taskKeys, key)
+		}
+		ri.tasks[key] = max(ri.tasks[key], drd.RunId)
+	}
+	for len(ri.taskKeys) > ri.maxTasks {
+		delete(ri.tasks, ri.taskKeys[0])
+		ri.taskKeys = ri.taskKeys[1:]
+	}
+	ri.Unlock()
+
+	if exists {
+		ri.store.saveRuns(api.UIDagrunList{row})
+		return
+	}
+	ri.addRuns(api.UIDagrunList{{
+		RunId:    drd.RunId,
+		DagId:    drd.DagId,
+		ExecTs:   drd.ExecTs,
+		Status:   drd.Status,
+		Duration: drd.Duration,
+	}})
+}
+
+// run returns DAG run of given ID, if it's in the index.
+func (ri *runIndex) run(runId int64) (api.UIDagrunRow, bool) {
+	ri.RLock()
+	defer ri.RUnlock()
+	row, ok := ri.runs[runId]
+	return row, ok
+}
+
+// dagIds returns sorted DAG IDs which contain given text. DAG IDs starting
+// with the text are listed first.
+func (ri *runIndex) dagIds(text string, limit int) []string {
+	ri.RLock()
+	seen := make(map[string]struct{})
+	for _, row := range ri.runs {
+		if strings.Contains(strings.ToLower(row.DagId), strings.ToLower(text)) {
+			seen[row.DagId] = struct{}{}
+		}
+	}
+	ri.RUnlock()
+
+	dagIds := make([]string, 0, len(seen))
+	for dagId := range seen {
+		dagIds = append(dagIds, dagId)
+	}
+	sort.Slice(dagIds, func(i, j int) bool {
+		pi := strings.HasPrefix(dagIds[i], text)
+		pj := strings.HasPrefix(dagIds[j], text)
+		if pi != pj {
+			return pi
+		}
+		return dagIds[i] < dagIds[j]
+	})
+	return dagIds[:min(limit, len(dagIds))]
+}
+
+// taskSuggestions returns recently seen tasks which IDs contain given text,
+// together with the latest DAG run they were seen in.
+func (ri *runIndex) taskSuggestions(text string, limit int) []TaskSuggestion {
+	ri.RLock()
+	suggestions := make([]TaskSuggestion, 0)
+	for key, runId := range ri.tasks {
+		if strings.Contains(strings.ToLower(key.TaskId), strings.ToLower(text)) {
+			suggestions = append(suggestions, TaskSuggestion{
+				DagId: key.DagId, TaskId: key.TaskId, RunId: runId,
+			})
+		}
+	}
+	ri.RUnlock()
+
+	sort.Slice(suggestions, func(i, j int) bool {
+		return suggestions[i].RunId > suggestions[j].RunId
+	})
+	return suggestions[:min(limit, len(suggestions))]
+}
+
+// findRuns returns the latest indexed DAG runs matching given predicate.
+func (ri *runIndex) findRuns(
+	match func(api.UIDagrunRow) bool, limit int,
+) api.UIDagrunList {
+	ri.RLock()
+	result := make(api.UIDagrunList, 0)
+	for _, row := range ri.runs {
+		if match(row) {
+			result = append(result, row)
+		}
+	}
+	ri.RUnlock()
+
+	sort.Slice(result, func(i, j int) bool {
+		return result[i].RunId > result[j].RunId
+	})
+	return result[:min(limit, len(result))]
+}
@@ -0,0 +1,141 @@
+package ui
+
+import (
+	"log/slog"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/ppacer/core/scheduler"
+)
+
+const (
+	// How often pages refresh the Scheduler state pill.
+	schedulerStatePollSeconds = 10
+
+	// Label of the Scheduler state pill, when the Scheduler cannot be
+	// reached.
+	schedulerUnreachable = "unreachable"
+)
+
+// SchedulerStatus represents the Scheduler state shown in the navbar. Err is
+// set, when the Scheduler is unreachable. Oob is set, when the banner is
+// rendered as an out-of-band swap together with the state pill.
+type SchedulerStatus struct {
+	State       scheduler.State
+	Reachable   bool
+	Err         string
+	CheckedAt   time.Time
+	PollSeconds int
+	Oob         bool
+}
+
+// Accepting checks if the Scheduler accepts new work. Only running Scheduler
+// schedules DAG runs and tasks, in other states actions are disabled.
+func (s SchedulerStatus) Accepting() bool {
+	return s.Reachable && s.State == scheduler.StateRunning
+}
+
+// Label returns lower-cased Scheduler state or "unreachable".
+func (s SchedulerStatus) Label() string {
+	if !s.Reachable {
+		return schedulerUnreachable
+	}
+	return strings.ToLower(s.State.String())
+}
+
+// BadgeClass returns daisyUI badge class matching the Scheduler state.
+func (s SchedulerStatus) BadgeClass() string {
+	switch {
+	case !s.Reachable || s.State == scheduler.StateStopped:
+		return "badge-error"
+	case s.State == scheduler.StateRunning:
+		return "badge-success"
+	default:
+		return "badge-warning"
+	}
+}
+
+// CheckedAtDisplay returns formatted time of the last state check.
+func (s SchedulerStatus) CheckedAtDisplay() string {
+	return s.CheckedAt.Format(auditDisplayFormat)
+}
+
+// readSchedulerStatus asks the Scheduler about its current state.
+func readSchedulerStatus(schedApi scheduler.API) SchedulerStatus {
+	status := SchedulerStatus{
+		CheckedAt:   time.Now(),
+		PollSeconds: schedulerStatePollSeconds,
+	}
+	state, err := schedApi.GetState()
+	if err != nil {
+		status.Err = err.Error()
+		return status
+	}
+	status.State, status.Reachable = state, true
+	return status
+}
+
+// schedulerGuard keeps the Scheduler state pill up to date and rejects
+// actions, when the Scheduler doesn't accept new work.
+type schedulerGuard struct {
+	templates *templates
+	schedApi  scheduler.API
+	logger    *slog.Logger
+}
+
+func newSchedulerGuard(
+	schedApi scheduler.API, tmpl *templates, logger *slog.Logger,
+) *schedulerGuard {
+	if logger == nil {
+		logger = defaultLogger()
+	}
+	return &schedulerGuard{
+		templates: tmpl,
+		schedApi:  schedApi,
+		logger:    logger,
+	}
+}
+
+// StateHandler renders the Scheduler state pill together with out-of-band
+// warning banner, which is empty when the Scheduler accepts new work.
+func (sg *schedulerGuard) StateHandler(w http.ResponseWriter, r *http.Request) {
+	status := sg.readStatus()
+	status.Oob = true
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	if err := sg.templates.Render(w, "scheduler_state", status); err != nil {
+		sg.logger.Error("Cannot render <scheduler_state>", "err",
+			err.Error())
+	}
+}
+
+// wrap returns handler which performs given action only when the Scheduler
+// accepts new work. Otherwise it responds with 503 and the warning banner,
+// which htmx swaps in place of the current banner.
+func (sg *schedulerGuard) wrap(action http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		status := sg.readStatus()
+		if status.Accepting() {
+			action(w, r)
+			return
+		}
+		sg.logger.Warn("Action rejected, Scheduler does not accept new work",
+			"path", r.URL.Path, "state", status.Label())
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+		w.Header().Set("HX-Retarget", "#scheduler-banner")
+		w.Header().Set("HX-Reswap", "outerHTML")
+		w.WriteHeader(http.StatusServiceUnavailable)
+		if err := sg.templates.Render(w, "scheduler_banner", status); err != nil {
+			sg.logger.Error("Cannot render <scheduler_banner>", "err",
+				err.Error())
+		}
+	}
+}
+
+func (sg *schedulerGuard) readStatus() SchedulerStatus {
+	status := readSchedulerStatus(sg.schedApi)
+	if !status.Reachable {
+		sg.logger.Warn("Cannot read Scheduler state", "err", status.Err)
+	}
+	return status
+}
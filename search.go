@@ -0,0 +1,201 @@
+package ui
+
+import (
+	"fmt"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/ppacer/core/api"
+	"github.com/ppacer/core/timeutils"
+)
+
+const (
+	searchPrefixDag    = "dag:"
+	searchPrefixStatus = "status:"
+	searchPrefixSince  = "since:"
+
+	// Maximum number of suggestions of each kind in search results.
+	maxSearchSuggestions = 5
+)
+
+// SearchQuery represents parsed query of the global search box. Query
+// consists of whitespace separated terms. Terms with dag:, status: and since:
+// prefixes filter DAG runs, the remaining terms are joined into Text. When
+// the whole query is a number (optionally prefixed by #), it's treated as DAG
+// run ID.
+type SearchQuery struct {
+	Raw      string
+	Text     string
+	DagId    string
+	Statuses []string
+	Since    string
+	RunId    int64
+	IsRunId  bool
+
+	sinceTs time.Time
+}
+
+// parseSearchQuery parses the global search query. Since might be a duration
+// (e.g. 30m, 24h or 7d) relative to now or a date in YYYY-MM-DD format.
+// Statuses are case insensitive and might be comma separated.
+func parseSearchQuery(raw string, now time.Time) (SearchQuery, error) {
+	q := SearchQuery{Raw: strings.TrimSpace(raw)}
+	if runId, err := strconv.ParseInt(strings.TrimPrefix(q.Raw, "#"), 10, 64); err == nil && runId >= 0 {
+		q.RunId, q.IsRunId = runId, true
+		return q, nil
+	}
+
+	texts := make([]string, 0)
+	for _, term := range strings.Fields(q.Raw) {
+		lower := strings.ToLower(term)
+		switch {
+		case strings.HasPrefix(lower, searchPrefixDag):
+			q.DagId = term[len(searchPrefixDag):]
+		case strings.HasPrefix(lower, searchPrefixStatus):
+			value := term[len(searchPrefixStatus):]
+			for _, status := range strings.Split(value, ",") {
+				if status == "" {
+					continue
+				}
+				status = strings.ToUpper(status)
+				if !slices.Contains(dagRunStatuses, status) {
+					return q, fmt.Errorf("unknown status %q, expected one of %s",
+						status, strings.Join(dagRunStatuses, ", "))
+				}
+				q.Statuses = append(q.Statuses, status)
+			}
+		case strings.HasPrefix(lower, searchPrefixSince):
+			q.Since = term[len(searchPrefixSince):]
+			if q.Since == "" {
+				continue
+			}
+			since, err := parseSince(q.Since, now)
+			if err != nil {
+				return q, err
+			}
+			q.sinceTs = since
+		default:
+			texts = append(texts, term)
+		}
+	}
+	q.Text = strings.Join(texts, " ")
+	return q, nil
+}
+
+// Parses since: value of the search query into a point in time.
+func parseSince(value string, now time.Time) (time.Time, error) {
+	if days, found := strings.CutSuffix(value, "d"); found {
+		if n, err := strconv.Atoi(days); err == nil && n >= 0 {
+			return now.AddDate(0, 0, -n), nil
+		}
+	}
+	if d, err := time.ParseDuration(value); err == nil && d >= 0 {
+		return now.Add(-d), nil
+	}
+	date, err := time.ParseInLocation("2006-01-02", value, timeutils.CurrentTz())
+	if err != nil {
+		return time.Time{}, fmt.Errorf("invalid since %q, expected duration "+
+			"(like 24h or 7d) or date YYYY-MM-DD", value)
+	}
+	return date, nil
+}
+
+// Empty checks if the query has no terms.
+func (q SearchQuery) Empty() bool { return q.Raw == "" }
+
+// HasFilters checks if any of prefixed terms is set.
+func (q SearchQuery) HasFilters() bool {
+	return q.DagId != "" || len(q.Statuses) > 0 || !q.sinceTs.IsZero()
+}
+
+// HistoryUrl returns URL of "History" page for the query.
+func (q SearchQuery) HistoryUrl() string {
+	return "/hist?q=" + url.QueryEscape(q.Raw)
+}
+
+// matches checks if given DAG run matches the query. DAG ID filter is a
+// prefix or a glob pattern, the same as on the latest DAG runs list. Free
+// text is matched against DAG ID, ignoring case.
+func (q SearchQuery) matches(row api.UIDagrunRow) bool {
+	if !(DagRunsFilter{DagId: q.DagId}).matchesDagId(row.DagId) {
+		return false
+	}
+	if q.Text != "" && !strings.Contains(strings.ToLower(row.DagId),
+		strings.ToLower(q.Text)) {
+		return false
+	}
+	return DagRunQuery{Statuses: q.Statuses, Since: q.sinceTs}.matches(row)
+}
+
+// Type pageSearch keeps dependencies required for the global search box in
+// the navbar.
+type pageSearch struct {
+	templates *templates
+	index     *runIndex
+	logger    *slog.Logger
+}
+
+// newPageSearch initialize new state for the global search.
+func newPageSearch(
+	index *runIndex, tmpl *templates, logger *slog.Logger,
+) *pageSearch {
+	if logger == nil {
+		logger = defaultLogger()
+	}
+	return &pageSearch{templates: tmpl, index: index, logger: logger}
+}
+
+// SearchResults represents suggestions rendered under the global search box.
+type SearchResults struct {
+	Query  SearchQuery
+	Run    api.UIDagrunRow
+	RunHit bool
+	DagIds []string
+	Tasks  []TaskSuggestion
+	Runs   api.UIDagrunList
+	Err    string
+}
+
+// HTTP handler which renders suggestions for the global search query given
+// by q query parameter. Suggestions are based on the index of recently seen
+// DAG runs, so Scheduler is not queried on every keystroke.
+func (ps *pageSearch) SearchHandler(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	q, err := parseSearchQuery(r.URL.Query().Get("q"), time.Now())
+	results := SearchResults{Query: q}
+	if err != nil {
+		results.Err = err.Error()
+	}
+	switch {
+	case err != nil || q.Empty():
+	case q.IsRunId:
+		results.Run, results.RunHit = ps.index.run(q.RunId)
+	default:
+		if q.Text != "" && !q.HasFilters() {
+			results.DagIds = ps.index.dagIds(q.Text, maxSearchSuggestions)
+			results.Tasks = ps.index.taskSuggestions(q.Text,
+				maxSearchSuggestions)
+		}
+		results.Runs = ps.index.findRuns(q.matches, maxSearchSuggestions)
+	}
+	if err := ps.templates.Render(w, "search_results", results); err != nil {
+		ps.logger.Error("Cannot render <search_results>", "err", err.Error())
+	}
+}
+
+// HTTP handler for submitting the global search form. DAG run ID redirects to
+// DAG run details, other queries redirect to "History" page.
+func (ps *pageSearch) GoHandler(w http.ResponseWriter, r *http.Request) {
+	q, _ := parseSearchQuery(r.URL.Query().Get("q"), time.Now())
+	if q.IsRunId {
+		http.Redirect(w, r, fmt.Sprintf("/dagruns/%d", q.RunId),
+			http.StatusSeeOther)
+		return
+	}
+	http.Redirect(w, r, q.HistoryUrl(), http.StatusSeeOther)
+}
@@ -0,0 +1,372 @@
+package ui
+
+import (
+	"fmt"
+	"log/slog"
+	"net/http"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/ppacer/core/api"
+)
+
+const (
+	sparklineWidth     = 120
+	sparklineHeight    = 24
+	sparklineMaxPoints = 60
+
+	statsChartWidth     = 800
+	statsChartHeight    = 160
+	statsChartLabelsW   = 50
+	statsChartAxisH     = 24
+	statsChartPadding   = 10
+	statsChartMaxPoints = 240
+	statsChartMaxTicks  = 8
+	statsChartTsFormat  = "2006-01-02 15:04:05"
+)
+
+// Time windows available on "Stats" page, in time.ParseDuration format.
+var statsWindows = []string{"1h", "6h", "12h", "24h"}
+
+// Time windows of "Stats" page longer than statsHistoryWindow, in
+// dagRunsWindows format. Samples from those windows are read from the history
+// store, so they are available only when the store is configured.
+var storedStatsWindows = []string{"7d", "30d"}
+
+const statsErr = "statsErr"
+
+// statsMetric represents a single numeric value of DAG runs statistics.
+type statsMetric struct {
+	Key   string
+	Title string
+	Value func(api.UIDagrunStats) int
+}
+
+// Metrics of DAG runs statistics which are drawn on charts.
+var statsMetrics = []statsMetric{
+	{
+		Key:   "runs_success",
+		Title: "Completed runs",
+		Value: func(s api.UIDagrunStats) int { return s.Dagruns.Success },
+	},
+	{
+		Key:   "runs_failed",
+		Title: "Failed runs",
+		Value: func(s api.UIDagrunStats) int { return s.Dagruns.Failed },
+	},
+	{
+		Key:   "runs_running",
+		Title: "Running runs",
+		Value: func(s api.UIDagrunStats) int { return s.Dagruns.Running },
+	},
+	{
+		Key:   "runs_scheduled",
+		Title: "Scheduled runs",
+		Value: func(s api.UIDagrunStats) int { return s.Dagruns.Scheduled },
+	},
+	{
+		Key:   "tasks_success",
+		Title: "Completed tasks",
+		Value: func(s api.UIDagrunStats) int { return s.DagrunTasks.Success },
+	},
+	{
+		Key:   "tasks_failed",
+		Title: "Failed tasks",
+		Value: func(s api.UIDagrunStats) int { return s.DagrunTasks.Failed },
+	},
+	{
+		Key:   "tasks_running",
+		Title: "Running tasks",
+		Value: func(s api.UIDagrunStats) int { return s.DagrunTasks.Running },
+	},
+	{
+		Key:   "tasks_scheduled",
+		Title: "Scheduled tasks",
+		Value: func(s api.UIDagrunStats) int { return s.DagrunTasks.Scheduled },
+	},
+	{
+		Key:   "tasks_queue",
+		Title: "Tasks queued",
+		Value: func(s api.UIDagrunStats) int { return s.TaskSchedulerQueueLen },
+	},
+	{
+		Key:   "runs_queue",
+		Title: "DAG runs queued",
+		Value: func(s api.UIDagrunStats) int { return s.DagrunQueueLen },
+	},
+	{
+		Key:   "goroutines",
+		Title: "Goroutines",
+		Value: func(s api.UIDagrunStats) int { return s.GoroutinesNum },
+	},
+}
+
+// Sparkline represents small line chart of a single metric, drawn under stats
+// cards.
+type Sparkline struct {
+	Width  int
+	Height int
+	Points string
+	Min    int
+	Max    int
+}
+
+// StatsChart represents full-size line chart of a single metric.
+type StatsChart struct {
+	Key    string
+	Title  string
+	Width  int
+	Height int
+	ChartX int
+	AxisY  int
+	Points string
+	Dots   []StatsChartDot
+	Ticks  []GanttTick
+	Min    int
+	Max    int
+	Last   int
+	MinY   int
+	MaxY   int
+}
+
+// StatsChartDot represents a single point on StatsChart.
+type StatsChartDot struct {
+	X     int
+	Y     int
+	Label string
+}
+
+// Point of a series, already scaled to chart coordinates.
+type seriesPoint struct {
+	X, Y  int
+	Ts    time.Time
+	Value int
+}
+
+// Prepares points of given metric scaled to the rectangle of given size
+// starting at (x0, y0). Samples are grouped into at most maxPoints buckets and
+// the maximum value of each bucket is used, so spikes are not lost. Points are
+// placed based on their time between from and to.
+func buildSeries(
+	samples []StatsSample, metric statsMetric, from, to time.Time,
+	x0, y0, width, height, maxPoints int,
+) ([]seriesPoint, int, int) {
+	if len(samples) == 0 {
+		return nil, 0, 0
+	}
+	bucketSize := (len(samples) + maxPoints - 1) / maxPoints
+	points := make([]seriesPoint, 0, maxPoints)
+	first := metric.Value(samples[0].Stats)
+	minV, maxV := first, first
+	for start := 0; start < len(samples); start += bucketSize {
+		end := min(start+bucketSize, len(samples))
+		point := seriesPoint{Ts: samples[end-1].Ts}
+		for i, s := range samples[start:end] {
+			v := metric.Value(s.Stats)
+			if i == 0 || v > point.Value {
+				point.Value = v
+			}
+			minV, maxV = min(minV, v), max(maxV, v)
+		}
+		points = append(points, point)
+	}
+
+	span := to.Sub(from)
+	if span <= 0 {
+		span = time.Second
+	}
+	scale := float64(width) / float64(span)
+	for i, p := range points {
+		points[i].X = x0 + int(float64(p.Ts.Sub(from))*scale)
+		if maxV == minV {
+			points[i].Y = y0 + height/2
+		} else {
+			ratio := float64(maxV-p.Value) / float64(maxV-minV)
+			points[i].Y = y0 + int(ratio*float64(height))
+		}
+	}
+	return points, minV, maxV
+}
+
+// Formats points as SVG polyline points attribute.
+func polylinePoints(points []seriesPoint) string {
+	var sb strings.Builder
+	for i, p := range points {
+		if i > 0 {
+			sb.WriteByte(' ')
+		}
+		fmt.Fprintf(&sb, "%d,%d", p.X, p.Y)
+	}
+	return sb.String()
+}
+
+// buildSparklines prepares sparklines of all statsMetrics based on given
+// samples. Sparklines are keyed by metric key.
+func buildSparklines(samples []StatsSample) map[string]Sparkline {
+	sparklines := make(map[string]Sparkline, len(statsMetrics))
+	if len(samples) < 2 {
+		return sparklines
+	}
+	from, to := samples[0].Ts, samples[len(samples)-1].Ts
+	for _, metric := range statsMetrics {
+		points, minV, maxV := buildSeries(samples, metric, from, to, 0, 1,
+			sparklineWidth, sparklineHeight-2, sparklineMaxPoints)
+		sparklines[metric.Key] = Sparkline{
+			Width:  sparklineWidth,
+			Height: sparklineHeight,
+			Points: polylinePoints(points),
+			Min:    minV,
+			Max:    maxV,
+		}
+	}
+	return sparklines
+}
+
+// buildStatsChart prepares full-size chart of given metric for samples taken
+// between from and to.
+func buildStatsChart(
+	samples []StatsSample, metric statsMetric, from, to time.Time,
+) StatsChart {
+	chart := StatsChart{
+		Key:    metric.Key,
+		Title:  metric.Title,
+		Width:  statsChartLabelsW + statsChartWidth + 2*statsChartPadding,
+		Height: statsChartPadding + statsChartHeight + statsChartAxisH,
+		ChartX: statsChartLabelsW,
+		AxisY:  statsChartPadding + statsChartHeight,
+		MinY:   statsChartPadding + statsChartHeight,
+		MaxY:   statsChartPadding,
+	}
+	points, minV, maxV := buildSeries(samples, metric, from, to,
+		statsChartLabelsW, statsChartPadding, statsChartWidth,
+		statsChartHeight, statsChartMaxPoints)
+	chart.Points = polylinePoints(points)
+	chart.Min, chart.Max = minV, maxV
+	for _, p := range points {
+		chart.Dots = append(chart.Dots, StatsChartDot{
+			X: p.X,
+			Y: p.Y,
+			Label: fmt.Sprintf("%s: %d", p.Ts.Format(statsChartTsFormat),
+				p.Value),
+		})
+	}
+	if len(points) > 0 {
+		chart.Last = points[len(points)-1].Value
+	}
+
+	span := to.Sub(from)
+	scale := float64(statsChartWidth) / float64(span)
+	step := ganttTickSteps[len(ganttTickSteps)-1]
+	for _, candidate := range ganttTickSteps {
+		if span/candidate <= statsChartMaxTicks {
+			step = candidate
+			break
+		}
+	}
+	tickFormat := "15:04"
+	if step >= 24*time.Hour {
+		tickFormat = "01-02"
+	}
+	for ts := from.Truncate(step).Add(step); !ts.After(to); ts = ts.Add(step) {
+		chart.Ticks = append(chart.Ticks, GanttTick{
+			X:     statsChartLabelsW + int(float64(ts.Sub(from))*scale),
+			Label: ts.Format(tickFormat),
+		})
+	}
+	return chart
+}
+
+// Parses window of "Stats" page. Stored is set, when samples from the window
+// have to be read from the history store. Zero duration is returned for
+// invalid window.
+func (ps *pageStats) parseWindow(name string) (window time.Duration, stored bool) {
+	if slices.Contains(storedStatsWindows, name) && ps.sampler.store != nil {
+		window, _ = windowDuration(name)
+		return window, true
+	}
+	window, err := time.ParseDuration(name)
+	if err != nil || window <= 0 || window > statsHistoryWindow {
+		return 0, false
+	}
+	return window, false
+}
+
+// Type pageStats keeps data required for "Stats" (/stats) page.
+type pageStats struct {
+	templates *templates
+	sampler   *statsSampler
+	logger    *slog.Logger
+}
+
+// StatsPage represents data rendered on "Stats" page.
+type StatsPage struct {
+	Page     string
+	Window   string
+	Windows  []string
+	Interval time.Duration
+	Samples  int
+	Charts   []StatsChart
+	Errors   map[string]string
+	Version  string
+}
+
+func newPageStats(
+	sampler *statsSampler, tmpl *templates, logger *slog.Logger,
+) *pageStats {
+	if logger == nil {
+		logger = defaultLogger()
+	}
+	return &pageStats{
+		templates: tmpl,
+		sampler:   sampler,
+		logger:    logger,
+	}
+}
+
+// Main handler for "Stats" page. It renders charts of all DAG runs statistics
+// metrics sampled within time window given by window query parameter. Samples
+// from windows longer than statsHistoryWindow are read from the history store.
+func (ps *pageStats) MainHandler(w http.ResponseWriter, r *http.Request) {
+	page := StatsPage{
+		Page:     "Runs",
+		Windows:  statsWindows,
+		Interval: ps.sampler.interval,
+		Charts:   make([]StatsChart, 0, len(statsMetrics)),
+		Errors:   map[string]string{},
+		Version:  Version,
+	}
+	if ps.sampler.store != nil {
+		page.Windows = append(slices.Clone(statsWindows), storedStatsWindows...)
+	}
+	windowStr := r.URL.Query().Get("window")
+	window, stored := ps.parseWindow(windowStr)
+	if window == 0 {
+		windowStr, window = statsWindows[len(statsWindows)-1], statsHistoryWindow
+	}
+	page.Window = windowStr
+	to := time.Now()
+	from := to.Add(-window)
+	samples := ps.sampler.history.since(from)
+	if stored {
+		var err error
+		samples, err = ps.sampler.store.statsSamples(from)
+		if err != nil {
+			ps.logger.Error("Cannot read stats samples from history store",
+				"window", windowStr, "err", err.Error())
+			page.Errors[statsErr] = "Cannot read stats samples: " + err.Error()
+		}
+	}
+	page.Samples = len(samples)
+	for _, metric := range statsMetrics {
+		page.Charts = append(page.Charts,
+			buildStatsChart(samples, metric, from, to))
+	}
+
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	renderErr := ps.templates.Render(w, "page_stats", page)
+	if renderErr != nil {
+		ps.logger.Error("Cannot render <page_stats>", "err",
+			renderErr.Error())
+	}
+}
@@ -0,0 +1,131 @@
+package ui
+
+import (
+	"context"
+	"log/slog"
+	"sync"
+	"time"
+
+	"github.com/ppacer/core/api"
+	"github.com/ppacer/core/scheduler"
+)
+
+// Time window of DAG runs statistics kept in memory.
+const statsHistoryWindow = 24 * time.Hour
+
+// StatsSample is a snapshot of DAG runs statistics taken at given time.
+type StatsSample struct {
+	Ts    time.Time
+	Stats api.UIDagrunStats
+}
+
+// statsHistory keeps the latest DAG runs statistics samples in a ring buffer
+// of fixed capacity. When the buffer is full, the oldest sample is
+// overwritten.
+type statsHistory struct {
+	sync.RWMutex
+	samples []StatsSample
+	next    int
+	full    bool
+}
+
+func newStatsHistory(capacity int) *statsHistory {
+	return &statsHistory{samples: make([]StatsSample, max(capacity, 1))}
+}
+
+// add appends new sample, possibly overwriting the oldest one.
+func (sh *statsHistory) add(s StatsSample) {
+	sh.Lock()
+	defer sh.Unlock()
+	sh.samples[sh.next] = s
+	sh.next = (sh.next + 1) % len(sh.samples)
+	if sh.next == 0 {
+		sh.full = true
+	}
+}
+
+// since returns samples taken not earlier than given time, starting from the
+// oldest one.
+func (sh *statsHistory) since(ts time.Time) []StatsSample {
+	sh.RLock()
+	defer sh.RUnlock()
+	start, size := 0, sh.next
+	if sh.full {
+		start, size = sh.next, len(sh.samples)
+	}
+	result := make([]StatsSample, 0, size)
+	for i := 0; i < size; i++ {
+		s := sh.samples[(start+i)%len(sh.samples)]
+		if !s.Ts.Before(ts) {
+			result = append(result, s)
+		}
+	}
+	return result
+}
+
+// statsSampler periodically reads DAG runs statistics from the Scheduler and
+// stores them in statsHistory and in the history store, when it's
+// configured.
+type statsSampler struct {
+	schedApi scheduler.API
+	history  *statsHistory
+	store    *historyStore
+	interval time.Duration
+	logger   *slog.Logger
+}
+
+// Creates new statsSampler with statsHistory big enough to keep samples from
+// the last statsHistoryWindow. Samples from that window are loaded from the
+// history store, when it's configured.
+func newStatsSampler(
+	schedApi scheduler.API, store *historyStore, interval time.Duration,
+	logger *slog.Logger,
+) *statsSampler {
+	if logger == nil {
+		logger = defaultLogger()
+	}
+	capacity := int(statsHistoryWindow / interval)
+	history := newStatsHistory(capacity)
+	samples, err := store.statsSamples(time.Now().Add(-statsHistoryWindow))
+	if err != nil {
+		logger.Error("Cannot load stats samples from history store", "err",
+			err.Error())
+	}
+	for _, s := range samples {
+		history.add(s)
+	}
+	return &statsSampler{
+		schedApi: schedApi,
+		history:  history,
+		store:    store,
+		interval: interval,
+		logger:   logger,
+	}
+}
+
+// run samples DAG runs statistics every interval, until ctx is done. It
+// should be started in a separate goroutine.
+func (ss *statsSampler) run(ctx context.Context) {
+	ticker := time.NewTicker(ss.interval)
+	defer ticker.Stop()
+	ss.sample()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			ss.sample()
+		}
+	}
+}
+
+func (ss *statsSampler) sample() {
+	stats, err := ss.schedApi.UIDagrunStats()
+	if err != nil {
+		ss.logger.Warn("Cannot sample DAG runs stats", "err", err.Error())
+		return
+	}
+	sample := StatsSample{Ts: time.Now(), Stats: stats}
+	ss.history.add(sample)
+	ss.store.saveStatsSample(sample)
+}
@@ -0,0 +1,562 @@
+package ui
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/ppacer/core/api"
+	_ "modernc.org/sqlite"
+)
+
+// Interval between removing records older than the retention period.
+const storeRetentionInterval = time.Hour
+
+// Migrations of historyStore database schema. Migration i moves schema from
+// version i to version i+1. Schema version is kept in SQLite user_version
+// pragma. Existing migrations must not be changed, new ones are appended.
+var storeMigrations = []string{
+	`
+	CREATE TABLE stats_samples (
+		ts_ms INTEGER NOT NULL,
+		runs_success INTEGER NOT NULL,
+		runs_failed INTEGER NOT NULL,
+		runs_scheduled INTEGER NOT NULL,
+		runs_running INTEGER NOT NULL,
+		tasks_success INTEGER NOT NULL,
+		tasks_failed INTEGER NOT NULL,
+		tasks_scheduled INTEGER NOT NULL,
+		tasks_running INTEGER NOT NULL,
+		dagrun_queue_len INTEGER NOT NULL,
+		task_queue_len INTEGER NOT NULL,
+		goroutines INTEGER NOT NULL
+	);
+	CREATE INDEX stats_samples_ts ON stats_samples (ts_ms);
+
+	CREATE TABLE dagruns (
+		run_id INTEGER PRIMARY KEY,
+		dag_id TEXT NOT NULL,
+		status TEXT NOT NULL,
+		duration TEXT NOT NULL,
+		row_json TEXT NOT NULL,
+		last_seen_ts_ms INTEGER NOT NULL
+	);
+	CREATE INDEX dagruns_dag_id ON dagruns (dag_id);
+	CREATE INDEX dagruns_last_seen ON dagruns (last_seen_ts_ms);
+
+	CREATE TABLE audit_events (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		ts_ms INTEGER NOT NULL,
+		user TEXT NOT NULL,
+		action TEXT NOT NULL,
+		run_id INTEGER NOT NULL,
+		dag_id TEXT NOT NULL,
+		task_id TEXT NOT NULL,
+		details TEXT NOT NULL,
+		reason TEXT NOT NULL,
+		err TEXT NOT NULL
+	);
+	CREATE INDEX audit_events_ts ON audit_events (ts_ms);
+
+	CREATE TABLE annotations (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		ts_ms INTEGER NOT NULL,
+		user TEXT NOT NULL,
+		run_id INTEGER NOT NULL,
+		task_id TEXT NOT NULL,
+		text TEXT NOT NULL
+	);
+	CREATE INDEX annotations_run_id ON annotations (run_id);
+	CREATE INDEX annotations_ts ON annotations (ts_ms);
+	`,
+	`
+	CREATE TABLE alert_rules (
+		name TEXT PRIMARY KEY,
+		metric TEXT NOT NULL,
+		dag_id TEXT NOT NULL,
+		condition TEXT NOT NULL,
+		threshold INTEGER NOT NULL,
+		window_ms INTEGER NOT NULL,
+		created_ts_ms INTEGER NOT NULL
+	);
+	`,
+}
+
+// historyStore persists data collected by the UI in SQLite database, so it
+// survives UI restarts. It keeps sampled DAG runs statistics, seen DAG runs,
+// audit events, annotations and alert rules added via the UI. Records older
+// than retention period are removed, except alert rules. All methods are safe
+// to call on nil *historyStore, in which case nothing is persisted. Errors on
+// saving records are only logged.
+type historyStore struct {
+	db        *sql.DB
+	retention time.Duration
+	logger    *slog.Logger
+}
+
+// openHistoryStore opens SQLite database under given path, creating it when
+// it doesn't exist, and migrates its schema to the latest version.
+func openHistoryStore(
+	path string, retention time.Duration, logger *slog.Logger,
+) (*historyStore, error) {
+	if logger == nil {
+		logger = defaultLogger()
+	}
+	db, err := sql.Open("sqlite", path)
+	if err != nil {
+		return nil, fmt.Errorf("cannot open SQLite database %s: %w", path, err)
+	}
+	// SQLite allows single writer, so using one connection avoids
+	// SQLITE_BUSY errors between concurrent UI requests.
+	db.SetMaxOpenConns(1)
+	if _, err := db.Exec("PRAGMA journal_mode=WAL"); err != nil {
+		db.Close()
+		return nil, fmt.Errorf("cannot set journal mode: %w", err)
+	}
+	hs := &historyStore{db: db, retention: retention, logger: logger}
+	if err := hs.migrate(); err != nil {
+		db.Close()
+		return nil, err
+	}
+	return hs, nil
+}
+
+// Applies migrations which were not applied yet, each in its own
+// transaction.
+func (hs *historyStore) migrate() error {
+	var version int
+	if err := hs.db.QueryRow("PRAGMA user_version").Scan(&version); err != nil {
+		return fmt.Errorf("cannot read schema version: %w", err)
+	}
+	if version > len(storeMigrations) {
+		return fmt.Errorf("schema version %d is newer than supported %d",
+			version, len(storeMigrations))
+	}
+	for v := version; v < len(storeMigrations); v++ {
+		tx, err := hs.db.Begin()
+		if err != nil {
+			return fmt.Errorf("cannot start migration %d: %w", v+1, err)
+		}
+		if _, err := tx.Exec(storeMigrations[v]); err != nil {
+			tx.Rollback()
+			return fmt.Errorf("cannot apply migration %d: %w", v+1, err)
+		}
+		pragma := fmt.Sprintf("PRAGMA user_version = %d", v+1)
+		if _, err := tx.Exec(pragma); err != nil {
+			tx.Rollback()
+			return fmt.Errorf("cannot set schema version %d: %w", v+1, err)
+		}
+		if err := tx.Commit(); err != nil {
+			return fmt.Errorf("cannot commit migration %d: %w", v+1, err)
+		}
+		hs.logger.Info("Applied history store migration", "version", v+1)
+	}
+	return nil
+}
+
+// close closes the underlying database.
+func (hs *historyStore) close() error {
+	if hs == nil {
+		return nil
+	}
+	return hs.db.Close()
+}
+
+// runRetention removes records older than the retention period every
+// storeRetentionInterval, until ctx is done. It should be started in a
+// separate goroutine.
+func (hs *historyStore) runRetention(ctx context.Context) {
+	if hs == nil || hs.retention <= 0 {
+		return
+	}
+	ticker := time.NewTicker(storeRetentionInterval)
+	defer ticker.Stop()
+	hs.removeOlderThan(time.Now().Add(-hs.retention))
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			hs.removeOlderThan(time.Now().Add(-hs.retention))
+		}
+	}
+}
+
+// Removes records older than given timestamp from all tables.
+func (hs *historyStore) removeOlderThan(ts time.Time) {
+	queries := []string{
+		"DELETE FROM stats_samples WHERE ts_ms < ?",
+		"DELETE FROM dagruns WHERE last_seen_ts_ms < ?",
+		"DELETE FROM audit_events WHERE ts_ms < ?",
+		"DELETE FROM annotations WHERE ts_ms < ?",
+	}
+	for _, query := range queries {
+		res, err := hs.db.Exec(query, ts.UnixMilli())
+		if err != nil {
+			hs.logger.Error("Cannot remove old records from history store",
+				"query", query, "err", err.Error())
+			continue
+		}
+		if n, _ := res.RowsAffected(); n > 0 {
+			hs.logger.Info("Removed old records from history store",
+				"query", query, "rows", n)
+		}
+	}
+}
+
+// saveStatsSample persists given DAG runs statistics sample.
+func (hs *historyStore) saveStatsSample(s StatsSample) {
+	if hs == nil {
+		return
+	}
+	_, err := hs.db.Exec(`
+		INSERT INTO stats_samples (
+			ts_ms, runs_success, runs_failed, runs_scheduled, runs_running,
+			tasks_success, tasks_failed, tasks_scheduled, tasks_running,
+			dagrun_queue_len, task_queue_len, goroutines
+		) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`,
+		s.Ts.UnixMilli(), s.Stats.Dagruns.Success, s.Stats.Dagruns.Failed,
+		s.Stats.Dagruns.Scheduled, s.Stats.Dagruns.Running,
+		s.Stats.DagrunTasks.Success, s.Stats.DagrunTasks.Failed,
+		s.Stats.DagrunTasks.Scheduled, s.Stats.DagrunTasks.Running,
+		s.Stats.DagrunQueueLen, s.Stats.TaskSchedulerQueueLen,
+		s.Stats.GoroutinesNum,
+	)
+	if err != nil {
+		hs.logger.Error("Cannot save stats sample", "err", err.Error())
+	}
+}
+
+// statsSamples reads DAG runs statistics samples taken not earlier than given
+// time, starting from the oldest one.
+func (hs *historyStore) statsSamples(since time.Time) ([]StatsSample, error) {
+	if hs == nil {
+		return nil, nil
+	}
+	rows, err := hs.db.Query(`
+		SELECT
+			ts_ms, runs_success, runs_failed, runs_scheduled, runs_running,
+			tasks_success, tasks_failed, tasks_scheduled, tasks_running,
+			dagrun_queue_len, task_queue_len, goroutines
+		FROM stats_samples
+		WHERE ts_ms >= ?
+		ORDER BY ts_ms`, since.UnixMilli())
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var samples []StatsSample
+	for rows.Next() {
+		var tsMs int64
+		var s api.UIDagrunStats
+		scanErr := rows.Scan(&tsMs, &s.Dagruns.Success, &s.Dagruns.Failed,
+			&s.Dagruns.Scheduled, &s.Dagruns.Running, &s.DagrunTasks.Success,
+			&s.DagrunTasks.Failed, &s.DagrunTasks.Scheduled,
+			&s.DagrunTasks.Running, &s.DagrunQueueLen,
+			&s.TaskSchedulerQueueLen, &s.GoroutinesNum)
+		if scanErr != nil {
+			return nil, scanErr
+		}
+		samples = append(samples, StatsSample{
+			Ts: time.UnixMilli(tsMs), Stats: s,
+		})
+	}
+	return samples, rows.Err()
+}
+
+// saveRuns inserts or updates given DAG runs. Status and duration of already
+// saved DAG runs are updated, so finished DAG runs keep their final status.
+func (hs *historyStore) saveRuns(runs api.UIDagrunList) {
+	if hs == nil || len(runs) == 0 {
+		return
+	}
+	err := hs.inTx(func(tx *sql.Tx) error {
+		now := time.Now().UnixMilli()
+		for _, run := range runs {
+			rowJson, jsonErr := json.Marshal(run)
+			if jsonErr != nil {
+				return jsonErr
+			}
+			_, err := tx.Exec(`
+				INSERT INTO dagruns (
+					run_id, dag_id, status, duration, row_json, last_seen_ts_ms
+				) VALUES (?, ?, ?, ?, ?, ?)
+				ON CONFLICT (run_id) DO UPDATE SET
+					dag_id = excluded.dag_id,
+					status = excluded.status,
+					duration = excluded.duration,
+					row_json = excluded.row_json,
+					last_seen_ts_ms = excluded.last_seen_ts_ms`,
+				run.RunId, run.DagId, run.Status, run.Duration,
+				string(rowJson), now,
+			)
+			if err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		hs.logger.Error("Cannot save DAG runs", "runs", len(runs), "err",
+			err.Error())
+	}
+}
+
+// latestRuns reads at most limit the most recently seen DAG runs, starting
+// from the least recently seen one.
+func (hs *historyStore) latestRuns(limit int) (api.UIDagrunList, error) {
+	if hs == nil {
+		return nil, nil
+	}
+	rows, err := hs.db.Query(`
+		SELECT row_json
+		FROM dagruns
+		ORDER BY last_seen_ts_ms DESC, run_id DESC
+		LIMIT ?`, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var runs api.UIDagrunList
+	for rows.Next() {
+		var rowJson string
+		if err := rows.Scan(&rowJson); err != nil {
+			return nil, err
+		}
+		var run api.UIDagrunRow
+		if err := json.Unmarshal([]byte(rowJson), &run); err != nil {
+			return nil, err
+		}
+		runs = append(runs, run)
+	}
+	slices.Reverse(runs)
+	return runs, rows.Err()
+}
+
+// queryRuns reads saved DAG runs matching given query, ordered from the newest
+// one. Execution time range is matched on the UI side, because execution
+// timestamps are kept only in serialized DAG run rows.
+func (hs *historyStore) queryRuns(q DagRunQuery) (api.UIDagrunList, error) {
+	if hs == nil {
+		return nil, nil
+	}
+	query := "SELECT row_json FROM dagruns WHERE 1 = 1"
+	var args []any
+	if q.DagId != "" {
+		query += " AND dag_id = ?"
+		args = append(args, q.DagId)
+	}
+	if q.BeforeRunId > 0 {
+		query += " AND run_id < ?"
+		args = append(args, q.BeforeRunId)
+	}
+	if len(q.Statuses) > 0 {
+		query += " AND status IN (?" +
+			strings.Repeat(", ?", len(q.Statuses)-1) + ")"
+		for _, status := range q.Statuses {
+			args = append(args, status)
+		}
+	}
+	rows, err := hs.db.Query(query+" ORDER BY run_id DESC", args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var runs api.UIDagrunList
+	for rows.Next() {
+		var rowJson string
+		if err := rows.Scan(&rowJson); err != nil {
+			return nil, err
+		}
+		var run api.UIDagrunRow
+		if err := json.Unmarshal([]byte(rowJson), &run); err != nil {
+			return nil, err
+		}
+		if !q.matches(run) {
+			continue
+		}
+		runs = append(runs, run)
+		if q.Limit > 0 && len(runs) == q.Limit {
+			break
+		}
+	}
+	return runs, rows.Err()
+}
+
+// saveAuditEvent persists given audit event.
+func (hs *historyStore) saveAuditEvent(e AuditEvent) {
+	if hs == nil {
+		return
+	}
+	_, err := hs.db.Exec(`
+		INSERT INTO audit_events (
+			ts_ms, user, action, run_id, dag_id, task_id, details, reason, err
+		) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?)`,
+		e.Ts.UnixMilli(), e.User, e.Action, e.RunId, e.DagId, e.TaskId,
+		e.Details, e.Reason, e.Err,
+	)
+	if err != nil {
+		hs.logger.Error("Cannot save audit event", "event", e, "err",
+			err.Error())
+	}
+}
+
+// latestAuditEvents reads at most limit the latest audit events, starting
+// from the oldest one.
+func (hs *historyStore) latestAuditEvents(limit int) ([]AuditEvent, error) {
+	if hs == nil {
+		return nil, nil
+	}
+	rows, err := hs.db.Query(`
+		SELECT ts_ms, user, action, run_id, dag_id, task_id, details, reason, err
+		FROM audit_events
+		ORDER BY id DESC
+		LIMIT ?`, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var events []AuditEvent
+	for rows.Next() {
+		var tsMs int64
+		var e AuditEvent
+		scanErr := rows.Scan(&tsMs, &e.User, &e.Action, &e.RunId, &e.DagId,
+			&e.TaskId, &e.Details, &e.Reason, &e.Err)
+		if scanErr != nil {
+			return nil, scanErr
+		}
+		e.Ts = time.UnixMilli(tsMs)
+		events = append(events, e)
+	}
+	slices.Reverse(events)
+	return events, rows.Err()
+}
+
+// saveAnnotation persists given DAG run annotation.
+func (hs *historyStore) saveAnnotation(a Annotation) {
+	if hs == nil {
+		return
+	}
+	_, err := hs.db.Exec(`
+		INSERT INTO annotations (ts_ms, user, run_id, task_id, text)
+		VALUES (?, ?, ?, ?, ?)`,
+		a.Ts.UnixMilli(), a.User, a.RunId, a.TaskId, a.Text,
+	)
+	if err != nil {
+		hs.logger.Error("Cannot save annotation", "annotation", a, "err",
+			err.Error())
+	}
+}
+
+// latestAnnotations reads annotations of at most maxRuns the most recently
+// annotated DAG runs, starting from the oldest annotation.
+func (hs *historyStore) latestAnnotations(maxRuns int) ([]Annotation, error) {
+	if hs == nil {
+		return nil, nil
+	}
+	rows, err := hs.db.Query(`
+		SELECT ts_ms, user, run_id, task_id, text
+		FROM annotations
+		WHERE run_id IN (
+			SELECT run_id
+			FROM annotations
+			GROUP BY run_id
+			ORDER BY MAX(id) DESC
+			LIMIT ?
+		)
+		ORDER BY id`, maxRuns)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var annotations []Annotation
+	for rows.Next() {
+		var tsMs int64
+		var a Annotation
+		scanErr := rows.Scan(&tsMs, &a.User, &a.RunId, &a.TaskId, &a.Text)
+		if scanErr != nil {
+			return nil, scanErr
+		}
+		a.Ts = time.UnixMilli(tsMs)
+		annotations = append(annotations, a)
+	}
+	return annotations, rows.Err()
+}
+
+// saveAlertRule persists given alert rule.
+func (hs *historyStore) saveAlertRule(r AlertRule) {
+	if hs == nil {
+		return
+	}
+	_, err := hs.db.Exec(`
+		INSERT INTO alert_rules (
+			name, metric, dag_id, condition, threshold, window_ms,
+			created_ts_ms
+		) VALUES (?, ?, ?, ?, ?, ?, ?)`,
+		r.Name, r.Metric, r.DagId, r.Condition, r.Threshold,
+		r.Window.Milliseconds(), time.Now().UnixMilli(),
+	)
+	if err != nil {
+		hs.logger.Error("Cannot save alert rule", "rule", r.Name, "err",
+			err.Error())
+	}
+}
+
+// deleteAlertRule removes alert rule of given name.
+func (hs *historyStore) deleteAlertRule(name string) {
+	if hs == nil {
+		return
+	}
+	_, err := hs.db.Exec("DELETE FROM alert_rules WHERE name = ?", name)
+	if err != nil {
+		hs.logger.Error("Cannot delete alert rule", "rule", name, "err",
+			err.Error())
+	}
+}
+
+// alertRules reads all alert rules in order they were created.
+func (hs *historyStore) alertRules() ([]AlertRule, error) {
+	if hs == nil {
+		return nil, nil
+	}
+	rows, err := hs.db.Query(`
+		SELECT name, metric, dag_id, condition, threshold, window_ms
+		FROM alert_rules
+		ORDER BY created_ts_ms, name`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var rules []AlertRule
+	for rows.Next() {
+		var windowMs int64
+		var r AlertRule
+		scanErr := rows.Scan(&r.Name, &r.Metric, &r.DagId, &r.Condition,
+			&r.Threshold, &windowMs)
+		if scanErr != nil {
+			return nil, scanErr
+		}
+		r.Window = time.Duration(windowMs) * time.Millisecond
+		rules = append(rules, r)
+	}
+	return rules, rows.Err()
+}
+
+// Runs given function in a transaction. The transaction is committed, when
+// the function returns nil error, otherwise it's rolled back.
+func (hs *historyStore) inTx(fn func(tx *sql.Tx) error) error {
+	tx, err := hs.db.Begin()
+	if err != nil {
+		return err
+	}
+	if err := fn(tx); err != nil {
+		tx.Rollback()
+		return err
+	}
+	return tx.Commit()
+}
@@ -0,0 +1,230 @@
+package ui
+
+import (
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/ppacer/core/api"
+	"github.com/ppacer/core/dag"
+)
+
+func TestHistoryStoreMigrateTwice(t *testing.T) {
+	hs := newTestHistoryStore(t)
+	if err := hs.migrate(); err != nil {
+		t.Fatalf("Second migration failed: %s", err.Error())
+	}
+	var version int
+	if err := hs.db.QueryRow("PRAGMA user_version").Scan(&version); err != nil {
+		t.Fatalf("Cannot read schema version: %s", err.Error())
+	}
+	if version != len(storeMigrations) {
+		t.Errorf("Expected schema version %d, got %d", len(storeMigrations),
+			version)
+	}
+}
+
+func TestHistoryStoreStatsSamples(t *testing.T) {
+	hs := newTestHistoryStore(t)
+	ts := time.UnixMilli(1729000000123)
+	sample := StatsSample{
+		Ts: ts,
+		Stats: api.UIDagrunStats{
+			Dagruns:               api.StatusCounts{Success: 1, Failed: 2, Scheduled: 3, Running: 4},
+			DagrunTasks:           api.StatusCounts{Success: 5, Failed: 6, Scheduled: 7, Running: 8},
+			DagrunQueueLen:        9,
+			TaskSchedulerQueueLen: 10,
+			GoroutinesNum:         11,
+		},
+	}
+	hs.saveStatsSample(StatsSample{Ts: ts.Add(-time.Hour)})
+	hs.saveStatsSample(sample)
+
+	samples, err := hs.statsSamples(ts.Add(-time.Minute))
+	if err != nil {
+		t.Fatalf("Cannot read stats samples: %s", err.Error())
+	}
+	if !reflect.DeepEqual(samples, []StatsSample{sample}) {
+		t.Errorf("Expected samples %+v, got %+v", []StatsSample{sample},
+			samples)
+	}
+}
+
+func TestHistoryStoreDagRuns(t *testing.T) {
+	hs := newTestHistoryStore(t)
+	now := time.Now()
+	runs := api.UIDagrunList{
+		testStoreRun(1, "dag_a", dag.RunSuccess, now.Add(-72*time.Hour)),
+		testStoreRun(2, "dag_b", dag.RunFailed, now.Add(-48*time.Hour)),
+		testStoreRun(3, "dag_a", dag.RunFailed, now.Add(-time.Hour)),
+	}
+	hs.saveRuns(runs)
+	updated := testStoreRun(3, "dag_a", dag.RunSuccess, now.Add(-time.Hour))
+	hs.saveRuns(api.UIDagrunList{updated})
+
+	latest, err := hs.latestRuns(10)
+	if err != nil {
+		t.Fatalf("Cannot read latest DAG runs: %s", err.Error())
+	}
+	if len(latest) != len(runs) {
+		t.Fatalf("Expected %d DAG runs, got %d", len(runs), len(latest))
+	}
+	if !reflect.DeepEqual(latest[len(latest)-1], updated) {
+		t.Errorf("Expected the most recently seen DAG run %+v, got %+v",
+			updated, latest[len(latest)-1])
+	}
+
+	tests := []struct {
+		name     string
+		query    DagRunQuery
+		expected []int64
+	}{
+		{"all", DagRunQuery{}, []int64{3, 2, 1}},
+		{"dag", DagRunQuery{DagId: "dag_a"}, []int64{3, 1}},
+		{"status", DagRunQuery{Statuses: []string{dag.RunFailed.String()}}, []int64{2}},
+		{"before", DagRunQuery{BeforeRunId: 3}, []int64{2, 1}},
+		{"since", DagRunQuery{Since: now.Add(-50 * time.Hour)}, []int64{3, 2}},
+		{"limit", DagRunQuery{Limit: 1}, []int64{3}},
+	}
+	for _, test := range tests {
+		rows, err := hs.queryRuns(test.query)
+		if err != nil {
+			t.Fatalf("Cannot query DAG runs (%s): %s", test.name, err.Error())
+		}
+		runIds := make([]int64, 0, len(rows))
+		for _, row := range rows {
+			runIds = append(runIds, row.RunId)
+		}
+		if !reflect.DeepEqual(runIds, test.expected) {
+			t.Errorf("Query %s: expected DAG runs %v, got %v", test.name,
+				test.expected, runIds)
+		}
+	}
+}
+
+func TestHistoryStoreAuditEvents(t *testing.T) {
+	hs := newTestHistoryStore(t)
+	events := []AuditEvent{
+		{
+			Ts: time.UnixMilli(1729000000000), User: "alice", Action: "restart",
+			RunId: 1, DagId: "dag_a",
+		},
+		{
+			Ts: time.UnixMilli(1729000001000), User: "bob", Action: "mark_task",
+			RunId: 2, DagId: "dag_b", TaskId: "task_1", Details: "retry 0",
+			Reason: "flaky", Err: "scheduler error",
+		},
+	}
+	for _, e := range events {
+		hs.saveAuditEvent(e)
+	}
+
+	saved, err := hs.latestAuditEvents(10)
+	if err != nil {
+		t.Fatalf("Cannot read audit events: %s", err.Error())
+	}
+	if !reflect.DeepEqual(saved, events) {
+		t.Errorf("Expected audit events %+v, got %+v", events, saved)
+	}
+	latest, err := hs.latestAuditEvents(1)
+	if err != nil {
+		t.Fatalf("Cannot read audit events: %s", err.Error())
+	}
+	if !reflect.DeepEqual(latest, events[1:]) {
+		t.Errorf("Expected the latest audit event %+v, got %+v", events[1:],
+			latest)
+	}
+}
+
+func TestHistoryStoreAnnotations(t *testing.T) {
+	hs := newTestHistoryStore(t)
+	annotations := []Annotation{
+		{Ts: time.UnixMilli(1729000000000), User: "alice", RunId: 1, Text: "a"},
+		{Ts: time.UnixMilli(1729000001000), User: "bob", RunId: 2, Text: "b"},
+		{
+			Ts: time.UnixMilli(1729000002000), User: "bob", RunId: 1,
+			TaskId: "task_1", Text: "c",
+		},
+	}
+	for _, a := range annotations {
+		hs.saveAnnotation(a)
+	}
+
+	saved, err := hs.latestAnnotations(10)
+	if err != nil {
+		t.Fatalf("Cannot read annotations: %s", err.Error())
+	}
+	if !reflect.DeepEqual(saved, annotations) {
+		t.Errorf("Expected annotations %+v, got %+v", annotations, saved)
+	}
+	latest, err := hs.latestAnnotations(1)
+	if err != nil {
+		t.Fatalf("Cannot read annotations: %s", err.Error())
+	}
+	expected := []Annotation{annotations[0], annotations[2]}
+	if !reflect.DeepEqual(latest, expected) {
+		t.Errorf("Expected annotations of the latest DAG run %+v, got %+v",
+			expected, latest)
+	}
+}
+
+func TestHistoryStoreAlertRules(t *testing.T) {
+	hs := newTestHistoryStore(t)
+	rules := []AlertRule{
+		{
+			Name: "failed", Metric: alertMetricDagFailedRuns, DagId: "dag_a",
+			Condition: ">", Threshold: 3, Window: time.Hour,
+		},
+		{
+			Name: "queue", Metric: "tasks_queue", Condition: ">=",
+			Threshold: 100, Window: 15 * time.Minute,
+		},
+	}
+	for _, r := range rules {
+		hs.saveAlertRule(r)
+		time.Sleep(2 * time.Millisecond)
+	}
+
+	saved, err := hs.alertRules()
+	if err != nil {
+		t.Fatalf("Cannot read alert rules: %s", err.Error())
+	}
+	if !reflect.DeepEqual(saved, rules) {
+		t.Errorf("Expected alert rules %+v, got %+v", rules, saved)
+	}
+
+	hs.deleteAlertRule("failed")
+	saved, err = hs.alertRules()
+	if err != nil {
+		t.Fatalf("Cannot read alert rules: %s", err.Error())
+	}
+	if !reflect.DeepEqual(saved, rules[1:]) {
+		t.Errorf("Expected alert rules %+v after delete, got %+v", rules[1:],
+			saved)
+	}
+}
+
+func newTestHistoryStore(t *testing.T) *historyStore {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "ui.db")
+	hs, err := openHistoryStore(path, 24*time.Hour, nil)
+	if err != nil {
+		t.Fatalf("Cannot open history store: %s", err.Error())
+	}
+	t.Cleanup(func() { hs.close() })
+	return hs
+}
+
+func testStoreRun(
+	runId int64, dagId string, status dag.RunStatus, execTs time.Time,
+) api.UIDagrunRow {
+	return api.UIDagrunRow{
+		RunId:    runId,
+		DagId:    dagId,
+		ExecTs:   api.ToTimestamp(execTs),
+		Status:   status.String(),
+		Duration: "1m0s",
+		TaskNum:  3,
+	}
+}
@@ -0,0 +1,249 @@
+package ui
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/ppacer/core/dag"
+)
+
+const (
+	auditActionClearTasks = "clear_tasks"
+)
+
+// Task statuses of tasks which can be retried or cleared.
+var clearableTaskStatuses = []string{
+	dag.TaskSuccess.String(),
+	dag.TaskFailed.String(),
+	dag.TaskUpstreamFailed.String(),
+}
+
+// TaskClearPreview represents tasks which would be reset by retrying a task
+// or clearing a task and its downstream. ByDepth is set, when DAG structure
+// is not available and downstream tasks are determined by their depth.
+type TaskClearPreview struct {
+	RunId      int64
+	TaskId     string
+	Retry      int
+	Downstream bool
+	ByDepth    bool
+	Tasks      []DagrunTask
+	Err        string
+}
+
+// Label returns name of the previewed action.
+func (p TaskClearPreview) Label() string {
+	if p.Downstream {
+		return "Clear this task and downstream"
+	}
+	return "Retry this task"
+}
+
+// Sets flags of actions available for the latest task attempts. CanClear is
+// set for finished tasks, when Scheduler client supports clearing tasks.
+// CanMark is set for all the latest attempts, including tasks which are not
+// started yet.
+func (pdrd *pageDagRunDetails) setTaskActions(tasks []DagrunTask) {
+	_, clearSupported := pdrd.schedApi.(DagRunTaskClearer)
+	latest := latestRetries(tasks)
+	for i, task := range tasks {
+		isLatest := task.Retry == latest[task.TaskId]
+		tasks[i].CanClear = clearSupported && isLatest &&
+			slices.Contains(clearableTaskStatuses, task.Status)
+		tasks[i].CanMark = isLatest
+	}
+}
+
+// HTTP handler which renders preview of tasks which would be reset by
+// retrying given task. When downstream query parameter is set, all tasks
+// downstream of given task are included.
+func (pdrd *pageDagRunDetails) TaskClearPreviewHandler(
+	w http.ResponseWriter, r *http.Request,
+) {
+	preview := TaskClearPreview{
+		Retry:      queryInt(r, "retry", 0),
+		Downstream: r.URL.Query().Get("downstream") != "",
+	}
+	runId, runErr := getPathValueInt(r, "runId")
+	taskId, taskErr := getPathValueStr(r, "taskId")
+	if runErr != nil || taskErr != nil {
+		pdrd.logger.Error("Invalid path arguments for TaskClearPreviewHandler",
+			"runErr", runErr, "taskErr", taskErr)
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+	preview.RunId, preview.TaskId = int64(runId), taskId
+	tasks, byDepth, err := pdrd.tasksToClear(runId, taskId, preview.Downstream)
+	if err != nil {
+		preview.Err = err.Error()
+	}
+	preview.Tasks, preview.ByDepth = tasks, byDepth
+
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	renderErr := pdrd.templates.Render(w, "task_clear_preview", preview)
+	if renderErr != nil {
+		pdrd.logger.Error("Cannot render <task_clear_preview>", "err",
+			renderErr.Error())
+	}
+}
+
+// HTTP handler which resets given task and, when downstream form value is
+// set, all tasks downstream of it. Reset tasks are computed again, the same
+// way as in the preview.
+func (pdrd *pageDagRunDetails) TaskClearHandler(
+	w http.ResponseWriter, r *http.Request,
+) {
+	runId, runErr := strconv.Atoi(r.FormValue("runId"))
+	taskId := r.FormValue("taskId")
+	downstream := r.FormValue("downstream") != ""
+	if runErr != nil || taskId == "" {
+		pdrd.logger.Error("Invalid input for clearing tasks", "runId",
+			r.FormValue("runId"), "taskId", taskId)
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+	retry, _ := strconv.Atoi(r.FormValue("retry"))
+	preview := TaskClearPreview{
+		RunId:      int64(runId),
+		TaskId:     taskId,
+		Retry:      retry,
+		Downstream: downstream,
+	}
+	clearer, ok := pdrd.schedApi.(DagRunTaskClearer)
+	tasks, _, err := pdrd.tasksToClear(runId, taskId, downstream)
+	if !ok {
+		err = errors.New("connected Scheduler does not support clearing tasks")
+	}
+	if err == nil {
+		taskIds := make([]string, len(tasks))
+		for i, task := range tasks {
+			taskIds[i] = task.TaskId
+		}
+		pdrd.logger.Info("Clearing tasks", "runId", runId, "taskIds", taskIds)
+		clearErr := clearer.ClearTasks(runId, taskIds)
+		event := AuditEvent{
+			User:    requestUser(r, pdrd.config.UserHeader),
+			Action:  auditActionClearTasks,
+			RunId:   int64(runId),
+			TaskId:  taskId,
+			Details: "reset tasks: " + strings.Join(taskIds, ", "),
+		}
+		if clearErr != nil {
+			event.Err = clearErr.Error()
+			pdrd.logger.Error("Error while clearing tasks", "runId", runId,
+				"taskIds", taskIds, "err", clearErr.Error())
+			err = errors.New("cannot reset tasks")
+		}
+		pdrd.audit.add(event)
+	}
+	if err != nil {
+		preview.Err = err.Error()
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+		renderErr := pdrd.templates.Render(w, "task_clear_preview", preview)
+		if renderErr != nil {
+			pdrd.logger.Error("Cannot render <task_clear_preview>", "err",
+				renderErr.Error())
+		}
+		return
+	}
+	w.Header().Set("HX-Redirect", fmt.Sprintf("/dagruns/%d", runId))
+	w.WriteHeader(http.StatusOK)
+}
+
+// Determines the latest attempts of tasks which would be reset by clearing
+// given task. Tasks are ordered by their position. The second returned value
+// is true, when downstream tasks were determined by depth, because DAG
+// structure is not available.
+func (pdrd *pageDagRunDetails) tasksToClear(
+	runId int, taskId string, downstream bool,
+) ([]DagrunTask, bool, error) {
+	drd, err := pdrd.schedApi.UIDagrunDetails(runId)
+	if err != nil {
+		pdrd.logger.Error("Cannot read DAG run details", "runId", runId, "err",
+			err.Error())
+		return nil, false, errors.New("cannot read DAG run details")
+	}
+	tasks := prepareDagrunTasks(drd.RunId, drd.Tasks, maxTaskIndent)
+	pdrd.setTaskActions(tasks)
+	retries := latestRetries(tasks)
+	latest := make([]DagrunTask, 0, len(tasks))
+	targetIdx := -1
+	for _, task := range tasks {
+		if task.Retry != retries[task.TaskId] {
+			continue
+		}
+		if task.TaskId == taskId {
+			targetIdx = len(latest)
+		}
+		latest = append(latest, task)
+	}
+	if targetIdx < 0 {
+		return nil, false, fmt.Errorf("task %s was not found in DAG run %d",
+			taskId, runId)
+	}
+	target := latest[targetIdx]
+	if !target.CanClear {
+		return nil, false, fmt.Errorf("task %s is %s and cannot be reset",
+			taskId, target.Status)
+	}
+	if !downstream {
+		return []DagrunTask{target}, false, nil
+	}
+	parents := pdrd.dagTaskParents(drd.DagId)
+	affected := downstreamTasks(target, latest, parents)
+	return affected, len(parents) == 0, nil
+}
+
+// downstreamTasks returns given task and all tasks downstream of it, in the
+// order of given tasks. When parents mapping is empty, tasks with greater
+// depth are treated as downstream.
+func downstreamTasks(
+	target DagrunTask, tasks []DagrunTask, parents map[string][]string,
+) []DagrunTask {
+	affected := map[string]bool{target.TaskId: true}
+	if len(parents) == 0 {
+		for _, task := range tasks {
+			if task.Pos.Depth > target.Pos.Depth {
+				affected[task.TaskId] = true
+			}
+		}
+	} else {
+		children := make(map[string][]string)
+		for child, taskParents := range parents {
+			for _, parent := range taskParents {
+				children[parent] = append(children[parent], child)
+			}
+		}
+		queue := []string{target.TaskId}
+		for len(queue) > 0 {
+			current := queue[0]
+			queue = queue[1:]
+			for _, child := range children[current] {
+				if !affected[child] {
+					affected[child] = true
+					queue = append(queue, child)
+				}
+			}
+		}
+	}
+	result := make([]DagrunTask, 0, len(affected))
+	for _, task := range tasks {
+		if affected[task.TaskId] {
+			result = append(result, task)
+		}
+	}
+	return result
+}
+
+// Returns the highest retry of each task.
+func latestRetries(tasks []DagrunTask) map[string]int {
+	retries := make(map[string]int, len(tasks))
+	for _, task := range tasks {
+		retries[task.TaskId] = max(retries[task.TaskId], task.Retry)
+	}
+	return retries
+}
@@ -0,0 +1,344 @@
+package ui
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"unicode"
+
+	"github.com/ppacer/core/api"
+)
+
+const (
+	configFormatJson = "JSON"
+	configFormatGo   = "Go"
+	configFormatText = "Text"
+
+	configIndent = "  "
+
+	configTokenKey    = "key"
+	configTokenString = "string"
+	configTokenNumber = "number"
+	configTokenBool   = "bool"
+	configTokenNull   = "null"
+	configTokenPunct  = "punct"
+	configTokenIdent  = "ident"
+)
+
+// ConfigView represents pretty-printed task config, split into lines of
+// tokens, which are highlighted based on their kind.
+type ConfigView struct {
+	Format string
+	Lines  []ConfigLine
+}
+
+// ConfigLine represents a single line of pretty-printed config.
+type ConfigLine struct {
+	Indent string
+	Tokens []ConfigToken
+}
+
+// ConfigToken represents a single token of task config, like a key, a string
+// or a punctuation character.
+type ConfigToken struct {
+	Text string
+	Kind string
+}
+
+// Text returns the line as plain text.
+func (cl ConfigLine) Text() string {
+	var sb strings.Builder
+	sb.WriteString(cl.Indent)
+	for _, token := range cl.Tokens {
+		sb.WriteString(token.Text)
+	}
+	return sb.String()
+}
+
+// TextLines returns pretty-printed config as plain text lines.
+func (cv ConfigView) TextLines() []string {
+	lines := make([]string, len(cv.Lines))
+	for i, line := range cv.Lines {
+		lines[i] = line.Text()
+	}
+	return lines
+}
+
+// ConfigView returns pretty-printed task config.
+func (drt DagrunTask) ConfigView() ConfigView {
+	return formatConfig(drt.Config)
+}
+
+// formatConfig pretty-prints task config. Both JSON and Go struct-like
+// configs, like {X:10,Y:"value"} or {X:10 Y:value}, are supported. Config
+// which does not look like any of them is split into lines as it is.
+func formatConfig(config string) ConfigView {
+	config = strings.TrimSpace(config)
+	if config == "" {
+		return ConfigView{Format: configFormatText}
+	}
+	format := configFormatText
+	switch {
+	case json.Valid([]byte(config)):
+		format = configFormatJson
+	case strings.ContainsAny(config, "{["):
+		format = configFormatGo
+	}
+	if format == configFormatText {
+		view := ConfigView{Format: format}
+		for _, line := range strings.Split(config, "\n") {
+			view.Lines = append(view.Lines, ConfigLine{
+				Tokens: []ConfigToken{{Text: line, Kind: configTokenIdent}},
+			})
+		}
+		return view
+	}
+	return ConfigView{Format: format, Lines: prettyConfigLines(tokenizeConfig(config))}
+}
+
+// Splits config into tokens. Quoted strings (including Go raw strings) are
+// single tokens, brackets, commas and colons are punctuation tokens and
+// everything else is split on whitespace.
+func tokenizeConfig(config string) []ConfigToken {
+	tokens := make([]ConfigToken, 0)
+	runes := []rune(config)
+	for i := 0; i < len(runes); {
+		r := runes[i]
+		switch {
+		case unicode.IsSpace(r):
+			i++
+		case strings.ContainsRune("{}[](),:", r):
+			tokens = append(tokens, ConfigToken{string(r), configTokenPunct})
+			i++
+		case r == '"' || r == '`':
+			j := i + 1
+			for j < len(runes) && runes[j] != r {
+				if r == '"' && runes[j] == '\\' {
+					j++
+				}
+				j++
+			}
+			end := min(j+1, len(runes))
+			tokens = append(tokens, ConfigToken{string(runes[i:end]),
+				configTokenString})
+			i = end
+		default:
+			j := i
+			for j < len(runes) && !unicode.IsSpace(runes[j]) &&
+				!strings.ContainsRune("{}[](),:\"`", runes[j]) {
+				j++
+			}
+			text := string(runes[i:j])
+			tokens = append(tokens, ConfigToken{text, valueTokenKind(text)})
+			i = j
+		}
+	}
+	// Tokens followed by colon are keys.
+	for i := 0; i+1 < len(tokens); i++ {
+		if tokens[i].Kind != configTokenPunct && tokens[i+1].Text == ":" {
+			tokens[i].Kind = configTokenKey
+		}
+	}
+	return tokens
+}
+
+func valueTokenKind(text string) string {
+	switch text {
+	case "true", "false":
+		return configTokenBool
+	case "null", "nil", "<nil>":
+		return configTokenNull
+	}
+	if _, err := strconv.ParseFloat(text, 64); err == nil {
+		return configTokenNumber
+	}
+	return configTokenIdent
+}
+
+// Lays out config tokens in lines. Objects and arrays are expanded, with
+// their elements indented. Elements separated by whitespace only (like in
+// Go's %v format) are placed in separate lines, when the next one is a key.
+func prettyConfigLines(tokens []ConfigToken) []ConfigLine {
+	lines := make([]ConfigLine, 0)
+	level := 0
+	current := ConfigLine{}
+	newLine := func() {
+		if len(current.Tokens) > 0 {
+			lines = append(lines, current)
+		}
+		current = ConfigLine{Indent: strings.Repeat(configIndent, level)}
+	}
+
+	for i := 0; i < len(tokens); i++ {
+		token := tokens[i]
+		switch token.Text {
+		case "{", "[":
+			if i+1 < len(tokens) && isClosing(tokens[i+1]) {
+				current.Tokens = append(current.Tokens, token, tokens[i+1])
+				i++
+				continue
+			}
+			current.Tokens = append(current.Tokens, token)
+			level++
+			newLine()
+			continue
+		case "}", "]":
+			level = max(level-1, 0)
+			newLine()
+			current.Tokens = append(current.Tokens, token)
+			continue
+		case ",":
+			current.Tokens = append(current.Tokens, token)
+			newLine()
+			continue
+		case ":":
+			current.Tokens = append(current.Tokens, ConfigToken{": ", configTokenPunct})
+			continue
+		}
+		if n := len(current.Tokens); n > 0 &&
+			current.Tokens[n-1].Kind != configTokenPunct {
+			if token.Kind == configTokenKey {
+				newLine()
+			} else {
+				current.Tokens = append(current.Tokens, ConfigToken{" ", configTokenPunct})
+			}
+		} else if n > 0 && isClosing(current.Tokens[n-1]) {
+			newLine()
+		}
+		current.Tokens = append(current.Tokens, token)
+	}
+	newLine()
+	return lines
+}
+
+func isClosing(token ConfigToken) bool {
+	return token.Kind == configTokenPunct && (token.Text == "}" || token.Text == "]")
+}
+
+// ConfigDiff represents diff between configs of two task attempts.
+type ConfigDiff struct {
+	TaskId    string
+	Label     string
+	BaseLabel string
+	Lines     []DiffLine
+	Changes   int
+	Err       string
+}
+
+// HTTP handler which renders diff between config of given task attempt and
+// config of another attempt of the same task (query parameter with=retry and
+// retry=N) or config of the task in the previous run of the DAG (with=prev).
+func (pdrd *pageDagRunDetails) TaskConfigDiffHandler(
+	w http.ResponseWriter, r *http.Request,
+) {
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	runId, taskId, retry, parseErr := parseTaskAttemptArgs(r)
+	cd := ConfigDiff{TaskId: taskId, Label: fmt.Sprintf("retry %d", retry)}
+	if parseErr != nil {
+		pdrd.logger.Error("Invalid path arguments for TaskConfigDiffHandler",
+			"err", parseErr.Error())
+		cd.Err = "Invalid arguments for config diff"
+		pdrd.renderConfigDiff(w, cd)
+		return
+	}
+
+	current, err := pdrd.schedApi.UIDagrunTaskDetails(runId, taskId, retry)
+	if err != nil {
+		pdrd.logger.Error("Cannot read task details", "runId", runId,
+			"taskId", taskId, "retry", retry, "err", err.Error())
+		cd.Err = "Cannot read task details"
+		pdrd.renderConfigDiff(w, cd)
+		return
+	}
+
+	var base string
+	switch r.URL.Query().Get("with") {
+	case "prev":
+		base, cd.BaseLabel, err = pdrd.previousRunTaskConfig(runId, taskId)
+	default:
+		baseRetry, atoiErr := strconv.Atoi(r.URL.Query().Get("retry"))
+		if atoiErr != nil {
+			cd.Err = "Invalid retry to compare with"
+			break
+		}
+		cd.BaseLabel = fmt.Sprintf("retry %d", baseRetry)
+		var task api.UIDagrunTask
+		task, err = pdrd.schedApi.UIDagrunTaskDetails(runId, taskId, baseRetry)
+		base = task.Config
+	}
+	if err != nil {
+		pdrd.logger.Error("Cannot read config to compare with", "runId", runId,
+			"taskId", taskId, "err", err.Error())
+		cd.Err = err.Error()
+	}
+	if cd.Err == "" {
+		cd.Lines = diffLines(
+			formatConfig(base).TextLines(),
+			formatConfig(current.Config).TextLines(),
+		)
+		cd.Changes = diffChanges(cd.Lines)
+	}
+	pdrd.renderConfigDiff(w, cd)
+}
+
+// Reads config of the latest attempt of given task in the previous run of the
+// same DAG. Returns config and label describing the DAG run.
+func (pdrd *pageDagRunDetails) previousRunTaskConfig(
+	runId int, taskId string,
+) (string, string, error) {
+	drd, err := pdrd.schedApi.UIDagrunDetails(runId)
+	if err != nil {
+		return "", "", fmt.Errorf("cannot read DAG run details: %w", err)
+	}
+	previous, err := pdrd.runs.query(DagRunQuery{
+		DagId: drd.DagId, BeforeRunId: drd.RunId, Limit: 1,
+	})
+	if err != nil {
+		return "", "", fmt.Errorf("cannot find previous DAG run: %w", err)
+	}
+	if len(previous) == 0 {
+		return "", "", fmt.Errorf("there is no previous run of DAG %s", drd.DagId)
+	}
+	prevRunId := int(previous[0].RunId)
+	prevDrd, err := pdrd.schedApi.UIDagrunDetails(prevRunId)
+	if err != nil {
+		return "", "", fmt.Errorf("cannot read previous DAG run details: %w", err)
+	}
+	found := false
+	var config string
+	lastRetry := -1
+	for _, task := range prevDrd.Tasks {
+		if task.TaskId == taskId && task.Retry > lastRetry {
+			config, lastRetry, found = task.Config, task.Retry, true
+		}
+	}
+	if !found {
+		return "", "", fmt.Errorf("task %s was not found in previous DAG run %d",
+			taskId, prevRunId)
+	}
+	return config, fmt.Sprintf("run %d (retry %d)", prevRunId, lastRetry), nil
+}
+
+func (pdrd *pageDagRunDetails) renderConfigDiff(w http.ResponseWriter, cd ConfigDiff) {
+	if err := pdrd.templates.Render(w, "task_config_diff", cd); err != nil {
+		pdrd.logger.Error("Cannot render <task_config_diff>", "err", err.Error())
+	}
+}
+
+// Sets OtherRetries for each task attempt, to compare its config with other
+// attempts of the same task.
+func setOtherRetries(tasks []DagrunTask) {
+	retries := make(map[string][]int)
+	for _, task := range tasks {
+		retries[task.TaskId] = append(retries[task.TaskId], task.Retry)
+	}
+	for i, task := range tasks {
+		for _, retry := range retries[task.TaskId] {
+			if retry != task.Retry {
+				tasks[i].OtherRetries = append(tasks[i].OtherRetries, retry)
+			}
+		}
+	}
+}
@@ -0,0 +1,169 @@
+package ui
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+)
+
+const (
+	// Number of task log records loaded at once.
+	taskLogsPageSize = 100
+
+	taskLogsModeOlder = "older"
+	taskLogsModeNewer = "newer"
+	taskLogsModeEnd   = "end"
+)
+
+// TaskLogsWindow represents consecutive log records of a DAG run task attempt,
+// starting from Offset. It's used to render log records together with
+// controls for loading older and newer log records.
+type TaskLogsWindow struct {
+	RunId     int64
+	TaskId    string
+	Retry     int
+	Offset    int
+	Total     int
+	Records   []TaskLogRecord
+	Paginated bool
+	Err       string
+}
+
+// End returns offset of the first log record after the window.
+func (tlw TaskLogsWindow) End() int {
+	return tlw.Offset + len(tlw.Records)
+}
+
+// HasOlder checks if there are log records before the window.
+func (tlw TaskLogsWindow) HasOlder() bool {
+	return tlw.Paginated && tlw.Offset > 0
+}
+
+// HasNewer checks if there are log records after the window.
+func (tlw TaskLogsWindow) HasNewer() bool {
+	return tlw.Paginated && tlw.End() < tlw.Total
+}
+
+// OlderOffset returns offset of the page of log records just before the
+// window.
+func (tlw TaskLogsWindow) OlderOffset() int {
+	return max(0, tlw.Offset-taskLogsPageSize)
+}
+
+// OlderLimit returns number of log records in the page just before the
+// window.
+func (tlw TaskLogsWindow) OlderLimit() int {
+	return tlw.Offset - tlw.OlderOffset()
+}
+
+// LastPageOffset returns offset of the last page of log records.
+func (tlw TaskLogsWindow) LastPageOffset() int {
+	return max(0, tlw.Total-taskLogsPageSize)
+}
+
+// PageSize returns number of log records loaded at once.
+func (tlw TaskLogsWindow) PageSize() int {
+	return taskLogsPageSize
+}
+
+// LogsWindow returns currently loaded task log records as TaskLogsWindow.
+func (drt DagrunTask) LogsWindow() TaskLogsWindow {
+	return TaskLogsWindow{
+		RunId:     drt.RunId,
+		TaskId:    drt.TaskId,
+		Retry:     drt.Retry,
+		Offset:    drt.TaskLogs.Offset,
+		Total:     drt.TaskLogs.LogRecordsCount,
+		Records:   drt.TaskLogs.Records,
+		Paginated: drt.LogsPaginated,
+	}
+}
+
+// HTTP handler which loads a page of DAG run task log records. Query
+// parameter mode determines if the page is rendered as older records (with
+// control for loading even older ones above), newer records (with control for
+// loading even newer ones below) or the last page of records.
+func (pdrd *pageDagRunDetails) TaskLogsHandler(
+	w http.ResponseWriter, r *http.Request,
+) {
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	window := TaskLogsWindow{Paginated: true}
+	mode := r.URL.Query().Get("mode")
+
+	runId, taskId, retry, parseErr := parseTaskAttemptArgs(r)
+	offset, limit, pageErr := parseLogsPageArgs(r)
+	window.RunId, window.TaskId, window.Retry = int64(runId), taskId, retry
+
+	logsReader, supported := pdrd.schedApi.(TaskLogsReader)
+	switch {
+	case parseErr != nil || pageErr != nil:
+		pdrd.logger.Error("Invalid arguments for TaskLogsHandler", "parseErr",
+			parseErr, "pageErr", pageErr)
+		window.Err = "Invalid arguments for loading task logs"
+	case !supported:
+		window.Err = "Connected Scheduler does not support loading more task logs"
+	default:
+		if mode == taskLogsModeEnd {
+			offset, limit = pdrd.lastLogsPage(logsReader, runId, taskId, retry)
+		}
+		logs, err := logsReader.UIDagrunTaskLogs(runId, taskId, retry, offset,
+			limit)
+		if err != nil {
+			pdrd.logger.Error("Cannot load task logs", "runId", runId, "taskId",
+				taskId, "retry", retry, "offset", offset, "err", err.Error())
+			window.Err = "Cannot load task logs"
+			break
+		}
+		window.Offset = offset
+		window.Total = logs.LogRecordsCount
+		window.Records = toTaskLogRecords(logs.Records)
+	}
+
+	tmpl := "task_logs_page_newer"
+	switch mode {
+	case taskLogsModeOlder:
+		tmpl = "task_logs_page_older"
+	case taskLogsModeEnd:
+		tmpl = "task_logs_page_end"
+	}
+	if renderErr := pdrd.templates.Render(w, tmpl, window); renderErr != nil {
+		pdrd.logger.Error("Cannot render task logs page", "template", tmpl,
+			"err", renderErr.Error())
+	}
+}
+
+// Finds offset and limit of the last page of log records. Total number of
+// records is read using request for an empty window.
+func (pdrd *pageDagRunDetails) lastLogsPage(
+	logsReader TaskLogsReader, runId int, taskId string, retry int,
+) (int, int) {
+	logs, err := logsReader.UIDagrunTaskLogs(runId, taskId, retry, 0, 0)
+	if err != nil {
+		pdrd.logger.Warn("Cannot read number of task log records", "runId",
+			runId, "taskId", taskId, "retry", retry, "err", err.Error())
+		return 0, taskLogsPageSize
+	}
+	return max(0, logs.LogRecordsCount-taskLogsPageSize), taskLogsPageSize
+}
+
+// parseLogsPageArgs parses offset and limit query parameters. When limit is
+// not given, taskLogsPageSize is used.
+func parseLogsPageArgs(r *http.Request) (int, int, error) {
+	offset, limit := 0, taskLogsPageSize
+	query := r.URL.Query()
+	if offsetStr := query.Get("offset"); offsetStr != "" {
+		var err error
+		offset, err = strconv.Atoi(offsetStr)
+		if err != nil || offset < 0 {
+			return -1, -1, fmt.Errorf("invalid offset: %s", offsetStr)
+		}
+	}
+	if limitStr := query.Get("limit"); limitStr != "" {
+		var err error
+		limit, err = strconv.Atoi(limitStr)
+		if err != nil || limit < 0 || limit > taskLogsPageSize {
+			return -1, -1, fmt.Errorf("invalid limit: %s", limitStr)
+		}
+	}
+	return offset, limit, nil
+}
@@ -0,0 +1,296 @@
+package ui
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"regexp"
+	"strconv"
+	"time"
+
+	"github.com/ppacer/core/api"
+)
+
+const (
+	taskLogsFormatText  = "text"
+	taskLogsFormatJsonl = "jsonl"
+	taskLogsFormatCsv   = "csv"
+)
+
+// Characters which are replaced in download filenames.
+var unsafeFilenameChars = regexp.MustCompile(`[^A-Za-z0-9._-]+`)
+
+// Formats of downloaded task logs.
+var taskLogsFormats = map[string]struct {
+	ext         string
+	contentType string
+}{
+	taskLogsFormatText:  {"log", "text/plain; charset=utf-8"},
+	taskLogsFormatJsonl: {"jsonl", "application/x-ndjson; charset=utf-8"},
+	taskLogsFormatCsv:   {"csv", "text/csv; charset=utf-8"},
+}
+
+// Task attempt, which log records are downloaded.
+type taskAttempt struct {
+	TaskId string
+	Retry  int
+}
+
+// Task log record, with DAG run and task information, as downloaded in JSONL
+// format.
+type taskLogRecordJson struct {
+	DagId          string `json:"dagId"`
+	RunId          int64  `json:"runId"`
+	TaskId         string `json:"taskId"`
+	Retry          int    `json:"retry"`
+	InsertTs       string `json:"insertTs"`
+	Level          string `json:"level"`
+	Message        string `json:"message"`
+	AttributesJson string `json:"attributesJson"`
+}
+
+// taskLogsWriter writes downloaded task log records in particular format.
+type taskLogsWriter interface {
+	Header() error
+	Write(dagId string, runId int64, attempt taskAttempt, rec TaskLogRecord) error
+	Flush() error
+}
+
+func newTaskLogsWriter(format string, w io.Writer) taskLogsWriter {
+	switch format {
+	case taskLogsFormatJsonl:
+		return &jsonlLogsWriter{enc: json.NewEncoder(w)}
+	case taskLogsFormatCsv:
+		return &csvLogsWriter{w: csv.NewWriter(w)}
+	default:
+		return &textLogsWriter{w: w}
+	}
+}
+
+type textLogsWriter struct {
+	w io.Writer
+}
+
+func (tw *textLogsWriter) Header() error { return nil }
+func (tw *textLogsWriter) Flush() error  { return nil }
+
+func (tw *textLogsWriter) Write(
+	_ string, _ int64, attempt taskAttempt, rec TaskLogRecord,
+) error {
+	_, err := fmt.Fprintf(tw.w, "%s [%s] %s (retry %d): %s %s\n",
+		formatLogTimestamp(rec.InsertTs), rec.Level, attempt.TaskId,
+		attempt.Retry, rec.Message, rec.AttributesJson)
+	return err
+}
+
+type jsonlLogsWriter struct {
+	enc *json.Encoder
+}
+
+func (jw *jsonlLogsWriter) Header() error { return nil }
+func (jw *jsonlLogsWriter) Flush() error  { return nil }
+
+func (jw *jsonlLogsWriter) Write(
+	dagId string, runId int64, attempt taskAttempt, rec TaskLogRecord,
+) error {
+	return jw.enc.Encode(taskLogRecordJson{
+		DagId:          dagId,
+		RunId:          runId,
+		TaskId:         attempt.TaskId,
+		Retry:          attempt.Retry,
+		InsertTs:       formatLogTimestamp(rec.InsertTs),
+		Level:          rec.Level,
+		Message:        rec.Message,
+		AttributesJson: rec.AttributesJson,
+	})
+}
+
+type csvLogsWriter struct {
+	w *csv.Writer
+}
+
+func (cw *csvLogsWriter) Header() error {
+	return cw.w.Write([]string{
+		"dagId", "runId", "taskId", "retry", "insertTs", "level", "message",
+		"attributesJson",
+	})
+}
+
+func (cw *csvLogsWriter) Write(
+	dagId string, runId int64, attempt taskAttempt, rec TaskLogRecord,
+) error {
+	return cw.w.Write([]string{
+		dagId, strconv.FormatInt(runId, 10), attempt.TaskId,
+		strconv.Itoa(attempt.Retry), formatLogTimestamp(rec.InsertTs),
+		rec.Level, rec.Message, rec.AttributesJson,
+	})
+}
+
+func (cw *csvLogsWriter) Flush() error {
+	cw.w.Flush()
+	return cw.w.Error()
+}
+
+// HTTP handler which streams all log records of a single DAG run task attempt
+// in format given by query parameter format (text, jsonl or csv).
+func (pdrd *pageDagRunDetails) TaskLogsDownloadHandler(
+	w http.ResponseWriter, r *http.Request,
+) {
+	runId, taskId, retry, parseErr := parseTaskAttemptArgs(r)
+	if parseErr != nil {
+		pdrd.logger.Error("Invalid path arguments for TaskLogsDownloadHandler",
+			"err", parseErr.Error())
+		http.Error(w, "invalid path arguments", http.StatusBadRequest)
+		return
+	}
+	attempt := taskAttempt{TaskId: taskId, Retry: retry}
+	pdrd.downloadTaskLogs(w, r, runId, []taskAttempt{attempt},
+		fmt.Sprintf("%s_retry%d", taskId, retry))
+}
+
+// HTTP handler which streams log records of all task attempts of a DAG run in
+// format given by query parameter format (text, jsonl or csv).
+func (pdrd *pageDagRunDetails) DagRunLogsDownloadHandler(
+	w http.ResponseWriter, r *http.Request,
+) {
+	runId, parseErr := getPathValueInt(r, "runId")
+	if parseErr != nil {
+		pdrd.logger.Error("Invalid runId for DagRunLogsDownloadHandler",
+			"err", parseErr.Error())
+		http.Error(w, "invalid runId", http.StatusBadRequest)
+		return
+	}
+	pdrd.downloadTaskLogs(w, r, runId, nil, "all_tasks")
+}
+
+// Streams log records of given task attempts. When attempts are nil, all task
+// attempts of the DAG run are downloaded. Records are flushed after each page,
+// so the response is sent using chunked transfer encoding.
+func (pdrd *pageDagRunDetails) downloadTaskLogs(
+	w http.ResponseWriter, r *http.Request, runId int,
+	attempts []taskAttempt, nameSuffix string,
+) {
+	format := r.URL.Query().Get("format")
+	if format == "" {
+		format = taskLogsFormatText
+	}
+	formatInfo, ok := taskLogsFormats[format]
+	if !ok {
+		http.Error(w, fmt.Sprintf("unsupported format: %s", format),
+			http.StatusBadRequest)
+		return
+	}
+
+	drd, err := pdrd.schedApi.UIDagrunDetails(runId)
+	if err != nil {
+		pdrd.logger.Error("Cannot read DAG run details for logs download",
+			"runId", runId, "err", err.Error())
+		http.Error(w, "cannot read DAG run details", http.StatusInternalServerError)
+		return
+	}
+	if attempts == nil {
+		for _, task := range drd.Tasks {
+			if task.TaskNoStarted {
+				continue
+			}
+			attempts = append(attempts, taskAttempt{task.TaskId, task.Retry})
+		}
+	}
+
+	filename := fmt.Sprintf("%s_run%d_%s.%s", drd.DagId, runId, nameSuffix,
+		formatInfo.ext)
+	w.Header().Set("Content-Type", formatInfo.contentType)
+	w.Header().Set("Content-Disposition", fmt.Sprintf(
+		"attachment; filename=%q", unsafeFilenameChars.ReplaceAllString(filename, "_"),
+	))
+
+	flusher, _ := w.(http.Flusher)
+	lw := newTaskLogsWriter(format, w)
+	writeErr := lw.Header()
+	for _, attempt := range attempts {
+		if writeErr != nil {
+			break
+		}
+		writeErr = pdrd.streamTaskLogs(runId, attempt, func(records []TaskLogRecord) error {
+			for _, rec := range records {
+				if err := lw.Write(drd.DagId, drd.RunId, attempt, rec); err != nil {
+					return err
+				}
+			}
+			if err := lw.Flush(); err != nil {
+				return err
+			}
+			if flusher != nil {
+				flusher.Flush()
+			}
+			return r.Context().Err()
+		})
+	}
+	if writeErr != nil {
+		pdrd.logger.Error("Cannot stream task logs", "runId", runId, "err",
+			writeErr.Error())
+	}
+}
+
+// Reads all log records of given task attempt page by page and calls fn for
+// each page. When Scheduler client does not support TaskLogsReader, only log
+// records returned by UIDagrunTaskDetails are read. In that case, when not all
+// records were returned, truncation marker record is added at the end, so the
+// downloaded file is not mistaken for complete logs.
+func (pdrd *pageDagRunDetails) streamTaskLogs(
+	runId int, attempt taskAttempt, fn func([]TaskLogRecord) error,
+) error {
+	logsReader, ok := pdrd.schedApi.(TaskLogsReader)
+	if !ok {
+		details, err := pdrd.schedApi.UIDagrunTaskDetails(runId,
+			attempt.TaskId, attempt.Retry)
+		if err != nil {
+			return err
+		}
+		records := toTaskLogRecords(details.TaskLogs.Records)
+		total := details.TaskLogs.LogRecordsCount
+		if len(records) < total {
+			pdrd.logger.Warn("Downloaded task logs are truncated", "runId",
+				runId, "taskId", attempt.TaskId, "retry", attempt.Retry,
+				"records", len(records), "total", total)
+			records = append(records, taskLogsTruncatedRecord(len(records),
+				total))
+		}
+		return fn(records)
+	}
+	for offset := 0; ; offset += taskLogsScanPageSize {
+		logs, err := logsReader.UIDagrunTaskLogs(runId, attempt.TaskId,
+			attempt.Retry, offset, taskLogsScanPageSize)
+		if err != nil {
+			return err
+		}
+		if fnErr := fn(toTaskLogRecords(logs.Records)); fnErr != nil {
+			return fnErr
+		}
+		if len(logs.Records) < taskLogsScanPageSize ||
+			offset+len(logs.Records) >= logs.LogRecordsCount {
+			return nil
+		}
+	}
+}
+
+// Log record which marks downloaded task logs as truncated.
+func taskLogsTruncatedRecord(read, total int) TaskLogRecord {
+	return TaskLogRecord{
+		InsertTs: api.ToTimestamp(time.Now()),
+		Level:    "WARN",
+		Message: fmt.Sprintf("TRUNCATED: only %d of %d log records were "+
+			"downloaded, Scheduler does not support reading all task logs",
+			read, total),
+	}
+}
+
+// Formats log record timestamp together with its timezone.
+func formatLogTimestamp(ts api.Timestamp) string {
+	if ts.Timezone == "" {
+		return ts.Date + " " + ts.Time
+	}
+	return ts.Date + " " + ts.Time + " " + ts.Timezone
+}
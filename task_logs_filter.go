@@ -0,0 +1,432 @@
+package ui
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+const (
+	// Number of log records read at once, while filtering task logs.
+	taskLogsScanPageSize = 1000
+
+	// Maximum number of log records scanned while filtering task logs.
+	maxScannedLogRecords = 50000
+
+	// Maximum number of rendered log records matching a filter.
+	maxFilteredLogRecords = 1000
+
+	// Maximum number of context records around a match.
+	maxLogsFilterContext = 10
+)
+
+// Log levels which can be toggled in task logs filter.
+var taskLogLevels = []string{"DEBUG", "INFO", "WARN", "ERROR"}
+
+// TaskLogsFilter represents filters for DAG run task log records. Empty
+// Levels means all levels, unless NoLevels is set, when all levels were
+// deselected explicitly. Query is a regular expression matched against log
+// messages. Context is the number of records shown around matching records.
+// When AttrPath is set, only records with attribute of this path and value
+// AttrValue are matched.
+type TaskLogsFilter struct {
+	Levels    []string
+	NoLevels  bool
+	Query     string
+	Context   int
+	AttrPath  string
+	AttrValue string
+}
+
+// parseTaskLogsFilter parses task logs filter from URL query values.
+func parseTaskLogsFilter(values url.Values) (TaskLogsFilter, error) {
+	filter := TaskLogsFilter{
+		Query:     values.Get("q"),
+		AttrPath:  values.Get("attr"),
+		AttrValue: values.Get("attrv"),
+	}
+	for _, level := range values["level"] {
+		if slices.Contains(taskLogLevels, level) {
+			filter.Levels = append(filter.Levels, level)
+		}
+	}
+	if len(filter.Levels) == len(taskLogLevels) {
+		filter.Levels = nil
+	}
+	// Form with level checkboxes sends levels parameter, so deselecting all
+	// of them can be distinguished from not filtering by level.
+	if len(filter.Levels) == 0 && values.Has("levels") {
+		filter.NoLevels = true
+	}
+	if ctxStr := values.Get("ctx"); ctxStr != "" {
+		ctx, err := strconv.Atoi(ctxStr)
+		if err != nil || ctx < 0 || ctx > maxLogsFilterContext {
+			return filter, fmt.Errorf("context should be an integer from [0, %d]",
+				maxLogsFilterContext)
+		}
+		filter.Context = ctx
+	}
+	return filter, nil
+}
+
+// Active checks if any filter is set.
+func (f TaskLogsFilter) Active() bool {
+	return len(f.Levels) > 0 || f.NoLevels || f.Query != "" ||
+		f.AttrPath != ""
+}
+
+// LevelOn checks if log records of given level pass the filter.
+func (f TaskLogsFilter) LevelOn(level string) bool {
+	if f.NoLevels {
+		return false
+	}
+	return len(f.Levels) == 0 || slices.Contains(f.Levels, level)
+}
+
+// Encode serializes filter as URL query parameters.
+func (f TaskLogsFilter) Encode() string {
+	values := url.Values{}
+	for _, level := range f.Levels {
+		values.Add("level", level)
+	}
+	if f.NoLevels {
+		values.Set("levels", "1")
+	}
+	if f.Query != "" {
+		values.Set("q", f.Query)
+	}
+	if f.Context > 0 {
+		values.Set("ctx", strconv.Itoa(f.Context))
+	}
+	if f.AttrPath != "" {
+		values.Set("attr", f.AttrPath)
+		values.Set("attrv", f.AttrValue)
+	}
+	return values.Encode()
+}
+
+// TaskLogsFiltered represents task log records filtered on the server side,
+// including counts of log records per level.
+type TaskLogsFiltered struct {
+	RunId     int64
+	TaskId    string
+	Retry     int
+	Filter    TaskLogsFilter
+	Levels    []LogLevelCount
+	Records   []FilteredLogRecord
+	Matches   int
+	Scanned   int
+	Total     int
+	Truncated bool
+	Err       string
+}
+
+// LogLevelCount represents number of log records of given level.
+type LogLevelCount struct {
+	Level string
+	Count int
+	On    bool
+}
+
+// FilteredLogRecord represents a log record which either matches the filter
+// or is context of a matching record. Message is split into segments, to
+// highlight parts matching the query. GapBefore is set, when records before
+// this one were skipped.
+type FilteredLogRecord struct {
+	Record    TaskLogRecord
+	Index     int
+	Match     bool
+	GapBefore bool
+	Segments  []TextSegment
+}
+
+// TextSegment is a part of a text, which might match a search query.
+type TextSegment struct {
+	Text  string
+	Match bool
+}
+
+// NoLogsFilter returns TaskLogsFiltered without any filter and unknown level
+// counts, to render filters form for not yet filtered task logs.
+func (drt DagrunTask) NoLogsFilter() TaskLogsFiltered {
+	levels := make([]LogLevelCount, len(taskLogLevels))
+	for i, level := range taskLogLevels {
+		levels[i] = LogLevelCount{Level: level, Count: -1, On: true}
+	}
+	return TaskLogsFiltered{
+		RunId:  drt.RunId,
+		TaskId: drt.TaskId,
+		Retry:  drt.Retry,
+		Levels: levels,
+	}
+}
+
+// filterTaskLogs filters given log records. Records are expected to be
+// ordered from the oldest one.
+func filterTaskLogs(
+	records []TaskLogRecord, filter TaskLogsFilter,
+) (TaskLogsFiltered, error) {
+	result := TaskLogsFiltered{Filter: filter, Scanned: len(records)}
+	var re *regexp.Regexp
+	if filter.Query != "" {
+		var compileErr error
+		re, compileErr = regexp.Compile(filter.Query)
+		if compileErr != nil {
+			return result, fmt.Errorf("invalid regular expression: %w",
+				compileErr)
+		}
+	}
+
+	result.Levels = logLevelCounts(records, filter)
+	matches := make([]bool, len(records))
+	for i, record := range records {
+		matches[i] = filter.LevelOn(record.Level) &&
+			(re == nil || re.MatchString(record.Message)) &&
+			(filter.AttrPath == "" ||
+				hasLogAttr(record.AttributesJson, filter.AttrPath, filter.AttrValue))
+	}
+
+	// Records are included when they match or are within context of a match.
+	included := make([]bool, len(records))
+	for i, match := range matches {
+		if !match {
+			continue
+		}
+		result.Matches++
+		from := max(0, i-filter.Context)
+		to := min(len(records)-1, i+filter.Context)
+		for j := from; j <= to; j++ {
+			included[j] = true
+		}
+	}
+
+	lastIdx := -1
+	for i, record := range records {
+		if !included[i] {
+			continue
+		}
+		if len(result.Records) == maxFilteredLogRecords {
+			result.Truncated = true
+			break
+		}
+		result.Records = append(result.Records, FilteredLogRecord{
+			Record:    record,
+			Index:     i,
+			Match:     matches[i],
+			GapBefore: lastIdx >= 0 && i > lastIdx+1,
+			Segments:  highlightSegments(record.Message, re, matches[i]),
+		})
+		lastIdx = i
+	}
+	return result, nil
+}
+
+// Counts log records of each level.
+func logLevelCounts(records []TaskLogRecord, filter TaskLogsFilter) []LogLevelCount {
+	counts := make(map[string]int)
+	for _, record := range records {
+		counts[record.Level]++
+	}
+	levels := make([]LogLevelCount, len(taskLogLevels))
+	for i, level := range taskLogLevels {
+		levels[i] = LogLevelCount{
+			Level: level,
+			Count: counts[level],
+			On:    filter.LevelOn(level),
+		}
+	}
+	return levels
+}
+
+// Splits given text into segments matching and not matching given regular
+// expression. Matches are highlighted only when highlight is set.
+func highlightSegments(
+	text string, re *regexp.Regexp, highlight bool,
+) []TextSegment {
+	if re == nil || !highlight {
+		return []TextSegment{{Text: text}}
+	}
+	segments := make([]TextSegment, 0)
+	prev := 0
+	for _, loc := range re.FindAllStringIndex(text, -1) {
+		if loc[0] == loc[1] {
+			continue
+		}
+		if loc[0] > prev {
+			segments = append(segments, TextSegment{Text: text[prev:loc[0]]})
+		}
+		segments = append(segments, TextSegment{
+			Text: text[loc[0]:loc[1]], Match: true,
+		})
+		prev = loc[1]
+	}
+	if prev < len(text) {
+		segments = append(segments, TextSegment{Text: text[prev:]})
+	}
+	return segments
+}
+
+// HTTP handler which filters DAG run task log records on the server side and
+// renders the result. When no filter is set, the first page of log records
+// is rendered. URL of DAG run details page including the filter is pushed to
+// the browser history, so it can be shared.
+func (pdrd *pageDagRunDetails) TaskLogsFilterHandler(
+	w http.ResponseWriter, r *http.Request,
+) {
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	runId, taskId, retry, parseErr := parseTaskAttemptArgs(r)
+	if parseErr != nil {
+		pdrd.logger.Error("Invalid path arguments for TaskLogsFilterHandler",
+			"err", parseErr.Error())
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+	filter, filterErr := parseTaskLogsFilter(r.URL.Query())
+
+	if filterErr == nil && !filter.Active() {
+		w.Header().Set("HX-Push-Url", fmt.Sprintf("/dagruns/%d", runId))
+		drt, err := pdrd.unfilteredTask(runId, taskId, retry)
+		if err != nil {
+			pdrd.logger.Error("Cannot read task logs", "runId", runId,
+				"taskId", taskId, "retry", retry, "err", err.Error())
+			drt.Errors = map[string]string{"logs": "Cannot read task logs"}
+		}
+		renderErr := pdrd.templates.Render(w, "task_logs_view", drt)
+		if renderErr != nil {
+			pdrd.logger.Error("Cannot render <task_logs_view>", "err",
+				renderErr.Error())
+		}
+		return
+	}
+
+	filtered := pdrd.filteredTaskLogs(runId, taskId, retry, filter)
+	if filterErr != nil {
+		filtered.Err = filterErr.Error()
+	}
+	w.Header().Set("HX-Push-Url", taskLogsFilterUrl(runId, taskId, retry, filter))
+	renderErr := pdrd.templates.Render(w, "task_logs_filtered", filtered)
+	if renderErr != nil {
+		pdrd.logger.Error("Cannot render <task_logs_filtered>", "err",
+			renderErr.Error())
+	}
+}
+
+// Reads all log records (up to maxScannedLogRecords) of given task attempt and
+// filters them. When Scheduler client does not support TaskLogsReader, only
+// log records returned by UIDagrunTaskDetails are filtered.
+func (pdrd *pageDagRunDetails) filteredTaskLogs(
+	runId int, taskId string, retry int, filter TaskLogsFilter,
+) TaskLogsFiltered {
+	records, total, readErr := pdrd.readAllTaskLogs(runId, taskId, retry)
+	filtered, err := filterTaskLogs(records, filter)
+	filtered.RunId, filtered.TaskId, filtered.Retry = int64(runId), taskId, retry
+	filtered.Total = total
+	if readErr != nil {
+		pdrd.logger.Error("Cannot read task logs for filtering", "runId",
+			runId, "taskId", taskId, "retry", retry, "err", readErr.Error())
+		filtered.Err = "Cannot read task logs"
+	}
+	if err != nil {
+		filtered.Err = err.Error()
+	}
+	return filtered
+}
+
+// Reads log records of given task attempt, up to maxScannedLogRecords. Total
+// number of log records is also returned.
+func (pdrd *pageDagRunDetails) readAllTaskLogs(
+	runId int, taskId string, retry int,
+) ([]TaskLogRecord, int, error) {
+	logsReader, ok := pdrd.schedApi.(TaskLogsReader)
+	if !ok {
+		details, err := pdrd.schedApi.UIDagrunTaskDetails(runId, taskId, retry)
+		if err != nil {
+			return nil, 0, err
+		}
+		return toTaskLogRecords(details.TaskLogs.Records),
+			details.TaskLogs.LogRecordsCount, nil
+	}
+
+	records := make([]TaskLogRecord, 0)
+	total := 0
+	for offset := 0; offset < maxScannedLogRecords; offset += taskLogsScanPageSize {
+		logs, err := logsReader.UIDagrunTaskLogs(runId, taskId, retry, offset,
+			taskLogsScanPageSize)
+		if err != nil {
+			return records, total, err
+		}
+		total = logs.LogRecordsCount
+		records = append(records, toTaskLogRecords(logs.Records)...)
+		if len(logs.Records) < taskLogsScanPageSize || len(records) >= total {
+			break
+		}
+	}
+	return records, total, nil
+}
+
+// Reads task attempt with the first page of log records, to render task logs
+// without any filters.
+func (pdrd *pageDagRunDetails) unfilteredTask(
+	runId int, taskId string, retry int,
+) (DagrunTask, error) {
+	drt := DagrunTask{
+		RunId:         int64(runId),
+		TaskId:        taskId,
+		Retry:         retry,
+		LogsPaginated: pdrd.logsPaginated(),
+	}
+	if logsReader, ok := pdrd.schedApi.(TaskLogsReader); ok {
+		logs, err := logsReader.UIDagrunTaskLogs(runId, taskId, retry, 0,
+			taskLogsPageSize)
+		drt.TaskLogs = toTaskLogs(logs)
+		return drt, err
+	}
+	details, err := pdrd.schedApi.UIDagrunTaskDetails(runId, taskId, retry)
+	drt.TaskLogs = toTaskLogs(details.TaskLogs)
+	return drt, err
+}
+
+// Opens logs window of the task attempt given in URL query (task and retry
+// parameters) and applies task logs filter from the query, if any.
+func (pdrd *pageDagRunDetails) applyLogsFilterFromUrl(values url.Values) {
+	taskId := values.Get("task")
+	retry, retryErr := strconv.Atoi(values.Get("retry"))
+	if taskId == "" || retryErr != nil {
+		return
+	}
+	for i, task := range pdrd.Details.Tasks {
+		if task.TaskId != taskId || task.Retry != retry {
+			continue
+		}
+		pdrd.Details.Tasks[i].LogsWindowOpen = true
+		filter, filterErr := parseTaskLogsFilter(values)
+		if filterErr == nil && !filter.Active() {
+			return
+		}
+		filtered := pdrd.filteredTaskLogs(int(task.RunId), taskId, retry,
+			filter)
+		if filterErr != nil {
+			filtered.Err = filterErr.Error()
+		}
+		pdrd.Details.Tasks[i].LogsFilter = &filtered
+		return
+	}
+}
+
+// Prepares URL of DAG run details page with task logs filter.
+func taskLogsFilterUrl(
+	runId int, taskId string, retry int, filter TaskLogsFilter,
+) string {
+	params := []string{
+		"task=" + url.QueryEscape(taskId),
+		"retry=" + strconv.Itoa(retry),
+	}
+	if encoded := filter.Encode(); encoded != "" {
+		params = append(params, encoded)
+	}
+	return fmt.Sprintf("/dagruns/%d?%s", runId, strings.Join(params, "&"))
+}
@@ -0,0 +1,252 @@
+package ui
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
+
+	"github.com/ppacer/core/api"
+)
+
+const (
+	taskRetriesErr = "taskRetriesErr"
+
+	// Kind of RetryLogRow, when log messages of both attempts differ at the
+	// same position. DiffLine never has this kind.
+	diffChanged = "changed"
+
+	// Maximum number of rows rendered in diff of log messages.
+	maxRetryLogDiffRows = 2000
+)
+
+// TaskRetries represents side-by-side comparison of all attempts of a single
+// DAG run task, including aligned diff of log messages of two attempts.
+type TaskRetries struct {
+	Page      string
+	RunId     int64
+	DagId     string
+	TaskId    string
+	Attempts  []RetryAttempt
+	DiffA     int
+	DiffB     int
+	LogDiff   []RetryLogRow
+	Changes   int
+	Truncated bool
+	Errors    map[string]string
+	Version   string
+}
+
+// RetryAttempt represents summary of a single task attempt.
+type RetryAttempt struct {
+	Retry        int
+	Status       string
+	Duration     string
+	Start        api.Timestamp
+	NotStarted   bool
+	Levels       []LogLevelCount
+	Records      int
+	Total        int
+	FirstError   string
+	FirstErrorTs string
+	messages     []string
+}
+
+// RetryLogRow represents a single row of aligned diff of log messages of two
+// task attempts. Kind is "same", "changed" (messages differ), "removed"
+// (message exists only in the left attempt) or "added" (only in the right
+// attempt).
+type RetryLogRow struct {
+	Kind  string
+	Left  string
+	Right string
+}
+
+// IsSame checks if the row is the same in both attempts.
+func (row RetryLogRow) IsSame() bool { return row.Kind == diffSame }
+
+// Path returns URL path of the task attempts comparison page.
+func (tr TaskRetries) Path() string {
+	return fmt.Sprintf("/dagruns/retries/%d/%s", tr.RunId,
+		url.PathEscape(tr.TaskId))
+}
+
+// RetriesLink returns URL of attempts comparison page, which diffs the task
+// attempt against the previous one. The first attempt is compared with the
+// next one.
+func (drt DagrunTask) RetriesLink() string {
+	a, b := -1, drt.Retry
+	for _, retry := range drt.OtherRetries {
+		if retry < drt.Retry && retry > a {
+			a = retry
+		}
+	}
+	if a < 0 {
+		a, b = drt.Retry, -1
+		for _, retry := range drt.OtherRetries {
+			if retry > drt.Retry && (b < 0 || retry < b) {
+				b = retry
+			}
+		}
+	}
+	return fmt.Sprintf("/dagruns/retries/%d/%s?a=%d&b=%d", drt.RunId,
+		url.PathEscape(drt.TaskId), a, b)
+}
+
+// HTTP handler which renders comparison of all attempts of a DAG run task.
+// Query parameters a and b select retries used for the diff of log messages.
+// By default the first and the last attempt are compared.
+func (pdrd *pageDagRunDetails) TaskRetriesHandler(
+	w http.ResponseWriter, r *http.Request,
+) {
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	tr := TaskRetries{Page: pdrd.Page, Errors: map[string]string{},
+		Version: pdrd.Version}
+	defer func() {
+		if err := pdrd.templates.Render(w, "page_task_retries", tr); err != nil {
+			pdrd.logger.Error("Cannot render <page_task_retries>", "err",
+				err.Error())
+		}
+	}()
+
+	runId, runErr := getPathValueInt(r, "runId")
+	taskId, taskErr := getPathValueStr(r, "taskId")
+	if runErr != nil || taskErr != nil {
+		pdrd.logger.Error("Invalid path arguments for TaskRetriesHandler",
+			"runErr", runErr, "taskErr", taskErr)
+		tr.Errors[taskRetriesErr] = "Invalid DAG run ID or task ID"
+		return
+	}
+	tr.RunId, tr.TaskId = int64(runId), taskId
+
+	drd, err := pdrd.schedApi.UIDagrunDetails(runId)
+	if err != nil {
+		pdrd.logger.Error("Cannot read DAG run details", "runId", runId, "err",
+			err.Error())
+		tr.Errors[taskRetriesErr] = "Cannot read DAG run details"
+		return
+	}
+	tr.DagId = drd.DagId
+
+	tasks := make([]api.UIDagrunTask, 0)
+	for _, task := range drd.Tasks {
+		if task.TaskId == taskId {
+			tasks = append(tasks, task)
+		}
+	}
+	if len(tasks) == 0 {
+		tr.Errors[taskRetriesErr] = fmt.Sprintf(
+			"Task %s was not found in DAG run %d", taskId, runId)
+		return
+	}
+	sort.Slice(tasks, func(i, j int) bool { return tasks[i].Retry < tasks[j].Retry })
+
+	for _, task := range tasks {
+		tr.Attempts = append(tr.Attempts, pdrd.retryAttempt(runId, task))
+	}
+
+	tr.DiffA = queryInt(r, "a", tasks[0].Retry)
+	tr.DiffB = queryInt(r, "b", tasks[len(tasks)-1].Retry)
+	a, aOk := findAttempt(tr.Attempts, tr.DiffA)
+	b, bOk := findAttempt(tr.Attempts, tr.DiffB)
+	if !aOk || !bOk {
+		tr.Errors[taskRetriesErr] = "Selected retries do not exist"
+		return
+	}
+	tr.LogDiff, tr.Changes, tr.Truncated = alignedLogDiff(a.messages, b.messages)
+}
+
+// Prepares summary of given task attempt, based on all of its log records.
+func (pdrd *pageDagRunDetails) retryAttempt(
+	runId int, task api.UIDagrunTask,
+) RetryAttempt {
+	attempt := RetryAttempt{
+		Retry:      task.Retry,
+		Status:     task.Status,
+		Duration:   task.Duration,
+		Start:      task.InsertTs,
+		NotStarted: task.TaskNoStarted,
+	}
+	if task.TaskNoStarted {
+		return attempt
+	}
+	records, total, err := pdrd.readAllTaskLogs(runId, task.TaskId, task.Retry)
+	if err != nil {
+		pdrd.logger.Error("Cannot read task logs for retries comparison",
+			"runId", runId, "taskId", task.TaskId, "retry", task.Retry, "err",
+			err.Error())
+	}
+	attempt.Records, attempt.Total = len(records), total
+	attempt.Levels = logLevelCounts(records, TaskLogsFilter{})
+	attempt.messages = make([]string, len(records))
+	errorFound := false
+	for i, record := range records {
+		attempt.messages[i] = fmt.Sprintf("[%s] %s", record.Level, record.Message)
+		if !errorFound && record.Level == "ERROR" {
+			attempt.FirstError = record.Message
+			attempt.FirstErrorTs = record.InsertTs.Time
+			errorFound = true
+		}
+	}
+	return attempt
+}
+
+// Computes diff of log messages of two attempts and aligns it into rows.
+// Consecutive removed and added messages are placed side by side.
+func alignedLogDiff(left, right []string) ([]RetryLogRow, int, bool) {
+	diff := diffLines(left, right)
+	rows := make([]RetryLogRow, 0, len(diff))
+	for i := 0; i < len(diff); {
+		if diff[i].IsSame() {
+			rows = append(rows, RetryLogRow{Kind: diffSame, Left: diff[i].Text,
+				Right: diff[i].Text})
+			i++
+			continue
+		}
+		removed, added := make([]string, 0), make([]string, 0)
+		for ; i < len(diff) && !diff[i].IsSame(); i++ {
+			if diff[i].IsRemoved() {
+				removed = append(removed, diff[i].Text)
+			} else {
+				added = append(added, diff[i].Text)
+			}
+		}
+		for j := 0; j < max(len(removed), len(added)); j++ {
+			var row RetryLogRow
+			switch {
+			case j < len(removed) && j < len(added):
+				row = RetryLogRow{diffChanged, removed[j], added[j]}
+			case j < len(removed):
+				row = RetryLogRow{diffRemoved, removed[j], ""}
+			default:
+				row = RetryLogRow{diffAdded, "", added[j]}
+			}
+			rows = append(rows, row)
+		}
+	}
+	changes := diffChanges(diff)
+	if len(rows) > maxRetryLogDiffRows {
+		return rows[:maxRetryLogDiffRows], changes, true
+	}
+	return rows, changes, false
+}
+
+func findAttempt(attempts []RetryAttempt, retry int) (RetryAttempt, bool) {
+	for _, attempt := range attempts {
+		if attempt.Retry == retry {
+			return attempt, true
+		}
+	}
+	return RetryAttempt{}, false
+}
+
+// Reads integer query parameter. Default value is returned, when the parameter
+// is not set or it's not an integer.
+func queryInt(r *http.Request, name string, defaultValue int) int {
+	value, err := strconv.Atoi(r.URL.Query().Get(name))
+	if err != nil {
+		return defaultValue
+	}
+	return value
+}
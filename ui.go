@@ -1,11 +1,14 @@
 package ui
 
 import (
+	"context"
 	"embed"
 	"io"
 	"log/slog"
 	"net/http"
+	"sync"
 	"text/template"
+	"time"
 
 	"github.com/ppacer/core/scheduler"
 )
@@ -16,11 +19,21 @@ var viewsFS embed.FS
 //go:embed assets/* css/*
 var staticFS embed.FS
 
-// UI represents ppacer UI.
+// UI represents ppacer UI. Background workers of the UI, like sampling DAG
+// runs statistics and evaluating alert rules, are started on creation and run
+// until Close is called.
 type UI struct {
 	logger       *slog.Logger
 	schedulerAPI scheduler.API
 	config       Config
+
+	store   *historyStore
+	runs    *dagRunSource
+	sampler *statsSampler
+	alerts  *alertManager
+	ctx     context.Context
+	cancel  context.CancelFunc
+	wg      sync.WaitGroup
 }
 
 // NewUI creates new instance of ppacer UI.
@@ -33,26 +46,100 @@ func NewUI(schedulerUrl string, logger *slog.Logger, config *Config) *UI {
 		config = &cnf
 	}
 	cfg := scheduler.DefaultClientConfig
-	return &UI{
+	ui := &UI{
 		logger:       logger,
 		schedulerAPI: scheduler.NewClient(schedulerUrl, nil, logger, cfg),
 		config:       *config,
 	}
+	ui.start()
+	return ui
 }
 
 // NewUIWithMocks creates new instance of ppacer UI which uses mocked Scheduler
 // in stead of actual ppacer Scheduler. It's meant primarily for local
 // development.
 func NewUIWithMocks(logger *slog.Logger, config *Config) *UI {
+	if logger == nil {
+		logger = defaultLogger()
+	}
 	if config == nil {
 		cnf := DefaultConfig
 		config = &cnf
 	}
-	return &UI{
+	ui := &UI{
 		logger:       logger,
-		schedulerAPI: SchedulerMock{},
+		schedulerAPI: NewSchedulerMock(),
 		config:       *config,
 	}
+	ui.start()
+	return ui
+}
+
+// AddAlertNotifier registers notifier of alert state changes.
+func (s *UI) AddAlertNotifier(notifier AlertNotifier) {
+	s.alerts.addNotifier(notifier)
+}
+
+// Close stops background workers and jobs (bulk restarts, backfills) of the
+// UI and closes the history store. The UI server should not be used after
+// Close.
+func (s *UI) Close() error {
+	s.cancel()
+	s.wg.Wait()
+	return s.store.close()
+}
+
+// Opens the history store and starts background workers: removing old records
+// from the store, sampling DAG runs statistics and evaluating alert rules.
+func (s *UI) start() {
+	ctx, cancel := context.WithCancel(context.Background())
+	s.ctx, s.cancel = ctx, cancel
+
+	// Optional persistent store of data collected by the UI
+	s.store = s.openHistoryStore()
+	s.runBackground(func() { s.store.runRetention(ctx) })
+
+	// DAG runs for history views and alert rules
+	s.runs = newDagRunSource(s.schedulerAPI, s.store)
+
+	// Background sampling of DAG runs statistics
+	sampleSeconds := s.config.StatsSampleSeconds
+	if sampleSeconds <= 0 {
+		sampleSeconds = DefaultConfig.StatsSampleSeconds
+	}
+	s.sampler = newStatsSampler(
+		s.schedulerAPI, s.store, time.Duration(sampleSeconds)*time.Second,
+		s.logger,
+	)
+	s.runBackground(func() { s.sampler.run(ctx) })
+
+	// Alert rules evaluated based on sampled statistics
+	notifiers := []AlertNotifier{newLogNotifier(s.logger)}
+	if s.config.AlertWebhookUrl != "" {
+		notifiers = append(notifiers,
+			NewWebhookNotifier(s.config.AlertWebhookUrl))
+	}
+	s.alerts = newAlertManager(
+		s.runs, s.sampler.history, s.store, s.config.AlertRules,
+		notifiers, s.sampler.interval, s.logger,
+	)
+	s.runBackground(func() { s.alerts.run(ctx) })
+	s.runBackground(func() { s.alerts.dispatch(ctx) })
+}
+
+// Runs given function in a separate goroutine, which is awaited by Close.
+func (s *UI) runBackground(fn func()) {
+	s.wg.Add(1)
+	go func() {
+		defer s.wg.Done()
+		fn()
+	}()
+}
+
+// Runs given job, like bulk restart or backfill, in the background. The job
+// should stop, when its context is cancelled by Close.
+func (s *UI) runJob(job func(ctx context.Context)) {
+	s.runBackground(func() { job(s.ctx) })
 }
 
 // Server set ups ppacer UI server which serves the web UI and provides
@@ -65,31 +152,162 @@ func (s *UI) Server() http.Handler {
 	mux.Handle("/assets/", http.FileServer(http.FS(staticFS)))
 	mux.Handle("/css/", http.FileServer(http.FS(staticFS)))
 
+	// Index of recently seen DAG runs, used by the global search
+	index := newRunIndex(maxIndexedRuns, maxIndexedTasks, s.store)
+
+	// Audit log of actions performed via the UI
+	audit := newAuditLog(maxAuditEvents, s.store, s.logger)
+
+	// Annotations of DAG runs, like reasons of manual status changes
+	annotations := newAnnotationStore(maxAnnotatedRuns, s.store)
+
+	// Scheduler state shown in the navbar on all pages. Actions are rejected,
+	// when the Scheduler doesn't accept new work.
+	guard := newSchedulerGuard(s.schedulerAPI, templates, s.logger)
+	mux.HandleFunc("GET /scheduler/state", guard.StateHandler)
+
 	// Page for DAG runs (main)
-	dagruns := newPageDagRuns(s.schedulerAPI, templates, s.logger, s.config)
+	dagruns := newPageDagRuns(
+		s.schedulerAPI, s.runs, index, s.sampler.history, templates,
+		s.logger, s.config,
+	)
 	mux.HandleFunc("/", dagruns.MainHandler)
 	mux.HandleFunc("GET /dagruns/stats", dagruns.StatsHandler)
 	mux.HandleFunc("GET /dagruns/latest", dagruns.ListHandler)
-	mux.HandleFunc("POST /dagruns/latest/len", dagruns.UpdateDagRunsNumHandler)
 	mux.HandleFunc("POST /dagruns/sync/stop", dagruns.SetSyncSeconds(1000000))
 	mux.HandleFunc("POST /dagruns/sync/start", dagruns.SetSyncSeconds(1))
 
 	// Page for DAG run details for given runId
-	drDetails := newPageDagRunDetails(s.schedulerAPI, templates, s.logger)
+	drDetails := newPageDagRunDetails(
+		s.schedulerAPI, s.runs, index, audit, annotations, templates,
+		s.logger, s.config,
+	)
 	mux.HandleFunc("/dagruns/{runId}", drDetails.MainHandler)
 	mux.HandleFunc(
 		"/dagruns/task/refresh/{runId}/{taskId}/{retry}/{taskPos}",
 		drDetails.RefreshSingleTaskDetailsHandler,
 	)
-	mux.HandleFunc("POST /dagruns/restart", drDetails.RestartDagRunHandler)
+	mux.HandleFunc("POST /dagruns/restart", guard.wrap(drDetails.RestartDagRunHandler))
+	mux.HandleFunc("POST /dagruns/cancel", guard.wrap(drDetails.CancelDagRunHandler))
+	mux.HandleFunc(
+		"POST /dagruns/cancel/task", guard.wrap(drDetails.CancelTaskHandler),
+	)
+	mux.HandleFunc(
+		"GET /dagruns/clear/{runId}/{taskId}",
+		drDetails.TaskClearPreviewHandler,
+	)
+	mux.HandleFunc("POST /dagruns/clear", guard.wrap(drDetails.TaskClearHandler))
+	mux.HandleFunc(
+		"GET /dagruns/mark/{runId}/{taskId}",
+		drDetails.TaskMarkFormHandler,
+	)
+	mux.HandleFunc("POST /dagruns/mark/task", guard.wrap(drDetails.MarkTaskHandler))
+	mux.HandleFunc("POST /dagruns/mark/run", guard.wrap(drDetails.MarkDagRunHandler))
+	mux.HandleFunc(
+		"GET /dagruns/logs/{runId}/{taskId}/{retry}",
+		drDetails.TaskLogsHandler,
+	)
+	mux.HandleFunc(
+		"GET /dagruns/logs/{runId}/{taskId}/{retry}/filter",
+		drDetails.TaskLogsFilterHandler,
+	)
+	mux.HandleFunc(
+		"GET /dagruns/logs/{runId}/{taskId}/{retry}/download",
+		drDetails.TaskLogsDownloadHandler,
+	)
+	mux.HandleFunc(
+		"GET /dagruns/logs/{runId}/download",
+		drDetails.DagRunLogsDownloadHandler,
+	)
+	mux.HandleFunc(
+		"GET /dagruns/config/{runId}/{taskId}/{retry}/diff",
+		drDetails.TaskConfigDiffHandler,
+	)
+	mux.HandleFunc(
+		"GET /dagruns/retries/{runId}/{taskId}",
+		drDetails.TaskRetriesHandler,
+	)
+
+	// Bulk actions on DAG runs selected on "Runs" and "History" pages
+	bulk := newPageBulk(
+		s.schedulerAPI, audit, s.runJob, templates, s.logger, s.config,
+	)
+	mux.HandleFunc("POST /dagruns/bulk/preview", bulk.PreviewHandler)
+	mux.HandleFunc("POST /dagruns/bulk/restart", guard.wrap(bulk.RestartHandler))
+	mux.HandleFunc("GET /dagruns/bulk/jobs/{jobId}", bulk.JobHandler)
+
+	// Page for comparing two DAG runs
+	compare := newPageCompare(s.schedulerAPI, templates, s.logger)
+	mux.HandleFunc("GET /compare", compare.MainHandler)
+
+	// Page for DAG runs history and the global search
+	history := newPageHistory(s.runs, index, templates, s.logger)
+	mux.HandleFunc("GET /hist", history.MainHandler)
+	search := newPageSearch(index, templates, s.logger)
+	mux.HandleFunc("GET /search", search.SearchHandler)
+	mux.HandleFunc("GET /search/go", search.GoHandler)
+
+	// Page for the audit log
+	auditPage := newPageAudit(audit, templates, s.logger)
+	mux.HandleFunc("GET /audit", auditPage.MainHandler)
+
+	// Page for DAG runs statistics charts
+	statsPage := newPageStats(s.sampler, templates, s.logger)
+	mux.HandleFunc("GET /stats", statsPage.MainHandler)
+	breakdown := newPageDagBreakdown(
+		s.schedulerAPI, s.runs, templates, s.logger,
+	)
+	mux.HandleFunc("GET /stats/dags", breakdown.MainHandler)
+
+	// Page for the Scheduler queues
+	queues := newPageQueues(s.schedulerAPI, templates, s.logger)
+	mux.HandleFunc("GET /queues", queues.MainHandler)
+
+	// Page for alerts
+	alertsPage := newPageAlerts(
+		s.alerts, audit, templates, s.logger, s.config,
+	)
+	mux.HandleFunc("GET /alerts", alertsPage.MainHandler)
+	mux.HandleFunc("GET /alerts/badge", alertsPage.BadgeHandler)
+	mux.HandleFunc("POST /alerts/rules", alertsPage.CreateRuleHandler)
+	mux.HandleFunc("POST /alerts/rules/delete", alertsPage.DeleteRuleHandler)
 
 	// Page for DAGs
-	dagsPage := newPageDags(s.schedulerAPI, templates, s.logger, s.config)
+	dagsPage := newPageDags(
+		s.schedulerAPI, audit, templates, s.logger, s.config,
+	)
 	mux.HandleFunc("/dags", dagsPage.MainHandler)
+	mux.HandleFunc("POST /dags/pause", guard.wrap(dagsPage.PauseHandler))
+	mux.HandleFunc("POST /dags/unpause", guard.wrap(dagsPage.UnpauseHandler))
+
+	// Page for backfilling DAG runs
+	backfill := newPageBackfill(
+		s.schedulerAPI, s.runJob, templates, s.logger, s.config,
+	)
+	mux.HandleFunc("/backfill", backfill.MainHandler)
+	mux.HandleFunc("POST /backfill/preview", backfill.PreviewHandler)
+	mux.HandleFunc("POST /backfill/start", guard.wrap(backfill.StartHandler))
+	mux.HandleFunc("GET /backfill/jobs/{jobId}", backfill.JobHandler)
 
 	return mux
 }
 
+// Opens history store, when Config.StorePath is set. When the store cannot be
+// opened, the error is logged and the UI works without persisting data.
+func (s *UI) openHistoryStore() *historyStore {
+	if s.config.StorePath == "" {
+		return nil
+	}
+	retention := time.Duration(s.config.StoreRetentionDays) * 24 * time.Hour
+	store, err := openHistoryStore(s.config.StorePath, retention, s.logger)
+	if err != nil {
+		s.logger.Error("Cannot open history store, data won't be persisted",
+			"path", s.config.StorePath, "err", err.Error())
+		return nil
+	}
+	return store
+}
+
 type templates struct {
 	templates *template.Template
 }